@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/enrichman/portfolio-performance/pkg/security/loaders"
+)
+
+// candidate is a catalog key resolved for a security, with the quotes fetched to preview it.
+type candidate struct {
+	loader string
+	key    string
+	quotes int
+	first  time.Time
+	last   time.Time
+	close  float32
+}
+
+func addCmd(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "securities.csv", "catalog file to append the security to")
+	loader := flags.String("loader", "", "loader to resolve the parameters with, instead of trying all of them ("+strings.Join(loaders.Resolvers, ", ")+")")
+	name := flags.String("name", "", "name of the security (required)")
+	options := flags.String("options", "", "options of the security, in the \"key=value;key=value\" format")
+	all := flags.Bool("all", false, "add all the listings found, instead of only the first one")
+	write := flags.Bool("write", false, "append the security to the catalog, instead of only previewing it")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: portfolio-performance add [flags] <ISIN or URL>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing ISIN or URL")
+	}
+	if *name == "" {
+		return errors.New("missing name - set it with -name")
+	}
+	input := strings.TrimSpace(flags.Arg(0))
+
+	resolvers := loaders.Resolvers
+	if *loader != "" {
+		resolvers = []string{*loader}
+	}
+
+	candidates, err := resolve(resolvers, input)
+	if err != nil {
+		return err
+	}
+
+	previewed := []candidate{}
+	for _, c := range candidates {
+		c, err := preview(c, *name)
+		if err != nil {
+			log.Warnf("[%s] %s: %s", c.loader, c.key, err)
+			continue
+		}
+		fmt.Printf("%s [%s]: %d quotes from %s to %s, last close %g\n", c.key, c.loader, c.quotes, c.first.Format(time.DateOnly), c.last.Format(time.DateOnly), c.close)
+		previewed = append(previewed, c)
+	}
+	if len(previewed) == 0 {
+		return fmt.Errorf("no quotes found for '%s'", input)
+	}
+	if !*all {
+		previewed = previewed[:1]
+	}
+
+	records := [][]string{}
+	for _, c := range previewed {
+		records = append(records, []string{c.key, *name, c.loader, *options})
+	}
+
+	return appendToCatalog(*catalogPath, records, *write)
+}
+
+// appendToCatalog appends the rows to the catalog, after checking them like the rest of the catalog
+// (i.e. for duplicated securities or IDs). Without write the rows are only printed.
+func appendToCatalog(catalogPath string, records [][]string, write bool) error {
+	catalogBytes, err := os.ReadFile(catalogPath)
+	if err != nil {
+		return fmt.Errorf("reading catalog: %w", err)
+	}
+
+	newCatalogBytes, firstLine := security.AppendToCatalog(catalogBytes, records...)
+
+	diagnostics, err := security.ValidateCatalog(newCatalogBytes, false)
+	if err != nil {
+		return fmt.Errorf("validating catalog: %w", err)
+	}
+	errorsFound := 0
+	for _, d := range diagnostics {
+		if d.Line < firstLine {
+			continue
+		}
+		fmt.Printf("%s:%s\n", catalogPath, d)
+		if d.Severity == security.SeverityError {
+			errorsFound++
+		}
+	}
+	if errorsFound > 0 {
+		return fmt.Errorf("the securities cannot be added: %d errors found", errorsFound)
+	}
+
+	if !write {
+		fmt.Print(string(newCatalogBytes[len(catalogBytes):]))
+		log.Infof("%d rows to append to '%s' - run with -write to add them", len(records), catalogPath)
+		return nil
+	}
+
+	if err := os.WriteFile(catalogPath, newCatalogBytes, 0644); err != nil {
+		return fmt.Errorf("writing catalog: %w", err)
+	}
+	log.Infof("%d rows appended to '%s' - rebuild the binary to publish them", len(records), catalogPath)
+	return nil
+}
+
+// resolve returns the keys resolved by the first loader that can resolve the input.
+func resolve(resolvers []string, input string) ([]candidate, error) {
+	errs := []error{}
+
+	for _, loader := range resolvers {
+		keys, err := loaders.Resolve(loader, input)
+		if err != nil {
+			log.Debugf("[%s] cannot resolve '%s': %s", loader, input, err)
+			errs = append(errs, fmt.Errorf("[%s] %w", loader, err))
+			continue
+		}
+
+		candidates := []candidate{}
+		for _, key := range keys {
+			candidates = append(candidates, candidate{loader: loader, key: key})
+		}
+		return candidates, nil
+	}
+
+	return nil, fmt.Errorf("cannot resolve '%s': %w", input, errors.Join(errs...))
+}
+
+// preview fetches the quotes of a candidate, checking its parameters first.
+func preview(c candidate, name string) (candidate, error) {
+	quoteLoader, err := loaders.New(c.loader, name, c.key)
+	if err != nil {
+		return c, err
+	}
+	if validator, ok := quoteLoader.(loaders.Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return c, err
+		}
+	}
+
+	quotesData, err := quoteLoader.LoadQuotes()
+	if err != nil {
+		return c, fmt.Errorf("error loading quotes: %w", err)
+	}
+	if len(quotesData) == 0 {
+		return c, errors.New("no quotes found")
+	}
+
+	c.quotes = len(quotesData)
+	c.first, c.last = quotesData[0].Date, quotesData[0].Date
+	for _, q := range quotesData {
+		if q.Date.Before(c.first) {
+			c.first = q.Date
+		}
+		if !q.Date.Before(c.last) {
+			c.last = q.Date
+			c.close = q.Close
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+)
+
+const (
+	// publicDir is the output folder of the public securities, published as a static site.
+	publicDir = "out"
+	// publicHistoryDir is the folder of the history of the public series, next to publicDir so it's not published.
+	publicHistoryDir = "history"
+)
+
+// catalogConfig selects the local overlay catalogs combined with the embedded one,
+// and the output folder of the private securities.
+type catalogConfig struct {
+	overlays   overlayFlags
+	privateDir string
+}
+
+// overlayFlags collects the repeated "-overlay path" flags.
+type overlayFlags []string
+
+func (o *overlayFlags) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overlayFlags) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+func (c *catalogConfig) register(flags *flag.FlagSet) {
+	flags.Var(&c.overlays, "overlay", "local catalog combined with the embedded one, with private securities by default (repeatable)")
+	flags.StringVar(&c.privateDir, "private-out", "private", "output folder of the private securities, never published")
+}
+
+// load loads the securities of the embedded catalog and of the overlays.
+func (c *catalogConfig) load() ([]*security.Security, error) {
+	return c.loadReplacing("", nil)
+}
+
+// loadReplacing loads the securities as load, with csvBytes in place of the catalog file at path:
+// one of the overlays, or else the source of the embedded catalog.
+func (c *catalogConfig) loadReplacing(path string, csvBytes []byte) ([]*security.Security, error) {
+	catalog := securities
+	if path != "" && !c.isOverlay(path) {
+		catalog = csvBytes
+	}
+
+	overlays := [][]byte{}
+	for _, overlay := range c.overlays {
+		if path != "" && filepath.Clean(overlay) == filepath.Clean(path) {
+			overlays = append(overlays, csvBytes)
+			continue
+		}
+
+		b, err := os.ReadFile(overlay)
+		if err != nil {
+			return nil, fmt.Errorf("reading overlay catalog: %w", err)
+		}
+		overlays = append(overlays, b)
+	}
+
+	loaded, err := security.LoadCatalogs(catalog, overlays...)
+	if err != nil {
+		return nil, fmt.Errorf("loading securities from CSV: %w", err)
+	}
+	return loaded, nil
+}
+
+// isOverlay returns true if the catalog file at path is one of the overlays.
+func (c *catalogConfig) isOverlay(path string) bool {
+	for _, overlay := range c.overlays {
+		if filepath.Clean(overlay) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// output is a folder the series of the securities with the same visibility are written in.
+type output struct {
+	dir        string
+	store      store.Store
+	visibility security.Visibility
+}
+
+// openOutputs opens the public output and, if the catalog has private securities, the private one.
+// The private series of a bolt store are kept in a database in the private folder.
+func openOutputs(storeCfg storeConfig, privateDir string, catalog []*security.Security) ([]output, error) {
+	st, err := storeCfg.open(filepath.Join(publicDir, "json"), publicHistoryDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening store: %w", err)
+	}
+	outputs := []output{{dir: publicDir, store: st, visibility: security.Public}}
+
+	if len(security.WithVisibility(catalog, security.Private)) == 0 {
+		return outputs, nil
+	}
+
+	if err := checkPrivateDir(privateDir); err != nil {
+		closeOutputs(outputs)
+		return nil, err
+	}
+
+	privateCfg := storeCfg
+	privateCfg.path = filepath.Join(privateDir, filepath.Base(storeCfg.path))
+
+	privateStore, err := privateCfg.open(filepath.Join(privateDir, "json"), filepath.Join(privateDir, "history"))
+	if err != nil {
+		closeOutputs(outputs)
+		return nil, fmt.Errorf("opening private store: %w", err)
+	}
+	return append(outputs, output{dir: privateDir, store: privateStore, visibility: security.Private}), nil
+}
+
+// outputOf returns the output of the series with the id, by the visibility of its security in the catalog.
+// The series not in the catalog are looked up in the public output.
+func outputOf(outputs []output, catalog []*security.Security, id string) output {
+	visibility := security.Public
+	for _, sec := range catalog {
+		if sec.ID() == id {
+			visibility = sec.Visibility
+		}
+	}
+
+	for _, out := range outputs {
+		if out.visibility == visibility {
+			return out
+		}
+	}
+	return outputs[0]
+}
+
+// checkPrivateDir checks the private output folder is not the published folder, or inside it.
+func checkPrivateDir(privateDir string) error {
+	public, err := filepath.Abs(publicDir)
+	if err != nil {
+		return fmt.Errorf("resolving the \"%s\" folder: %w", publicDir, err)
+	}
+	private, err := filepath.Abs(privateDir)
+	if err != nil {
+		return fmt.Errorf("resolving the private output folder: %w", err)
+	}
+
+	rel, err := filepath.Rel(public, private)
+	if err != nil {
+		return fmt.Errorf("resolving the private output folder: %w", err)
+	}
+	if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("the private output folder \"%s\" cannot be in the published \"%s\" folder", privateDir, publicDir)
+	}
+	return nil
+}
+
+func closeOutputs(outputs []output) {
+	for _, out := range outputs {
+		out.store.Close()
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPrivateDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	abs, err := filepath.Abs(".")
+	require.Nil(t, err)
+
+	tt := []struct {
+		privateDir string
+		valid      bool
+	}{
+		{privateDir: "private", valid: true},
+		{privateDir: "../private", valid: true},
+		{privateDir: "outside", valid: true},
+		{privateDir: "out-private", valid: true},
+		{privateDir: "..out", valid: true},
+		{privateDir: "out", valid: false},
+		{privateDir: "./out/", valid: false},
+		{privateDir: "out/private", valid: false},
+		{privateDir: "out/json/../private", valid: false},
+		{privateDir: "private/../out", valid: false},
+		{privateDir: filepath.Join(abs, "out"), valid: false},
+		{privateDir: filepath.Join(abs, "out", "private"), valid: false},
+	}
+
+	for _, tc := range tt {
+		err := checkPrivateDir(tc.privateDir)
+		if tc.valid {
+			assert.Nil(t, err, tc.privateDir)
+		} else {
+			assert.NotNil(t, err, tc.privateDir)
+		}
+	}
+}
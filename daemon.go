@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/scheduler"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+const (
+	// defaultSchedule updates the quotes every day after the markets close.
+	defaultSchedule = "0 19 * * *"
+	// defaultWeeklySchedule is used for the loaders with only monthly series, i.e. pension funds,
+	// checked every Monday since their NAVs are published some days after the end of the month.
+	defaultWeeklySchedule = "0 7 * * 1"
+)
+
+// scheduleFlags collects the repeated "-schedule loader=spec" flags.
+type scheduleFlags map[string]*scheduler.Schedule
+
+func (s scheduleFlags) String() string {
+	specs := []string{}
+	for loader, schedule := range s {
+		specs = append(specs, fmt.Sprintf("%s=%s", loader, schedule))
+	}
+	return strings.Join(specs, ", ")
+}
+
+func (s scheduleFlags) Set(value string) error {
+	loader, spec, found := strings.Cut(value, "=")
+	if !found {
+		return fmt.Errorf("wrong schedule \"%s\" - should be \"loader=spec\"", value)
+	}
+
+	schedule, err := scheduler.Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	s[loader] = schedule
+	return nil
+}
+
+func daemonCmd(args []string) error {
+	schedules := scheduleFlags{}
+
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	flags.Var(schedules, "schedule", "cron-like schedule (in UTC) of a loader, i.e. \"borsaitaliana=0 19 * * 1-5\" (repeatable)")
+	jitter := flags.Duration("jitter", 5*time.Minute, "maximum random delay added to every run")
+	statePath := flags.String("state", "daemon-state.json", "file the last run of every loader is persisted to")
+	var storeCfg storeConfig
+	var catalogCfg catalogConfig
+	storeCfg.register(flags)
+	catalogCfg.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	catalog, err := catalogCfg.load()
+	if err != nil {
+		return err
+	}
+
+	outputs, err := openOutputs(storeCfg, catalogCfg.privateDir, catalog)
+	if err != nil {
+		return err
+	}
+	defer closeOutputs(outputs)
+
+	byLoader := map[string][]*security.Security{}
+	for _, sec := range catalog {
+		byLoader[sec.Loader] = append(byLoader[sec.Loader], sec)
+	}
+
+	for loader := range schedules {
+		if _, found := byLoader[loader]; !found {
+			return fmt.Errorf("no securities found for loader [%s]", loader)
+		}
+	}
+
+	// the runs are serialized, since they all write the report, health and manifest
+	var mu sync.Mutex
+
+	daemon := &scheduler.Daemon{
+		Jitter:    *jitter,
+		StatePath: *statePath,
+	}
+
+	for loader, loaders := range byLoader {
+		schedule, found := schedules[loader]
+		if !found {
+			schedule = scheduler.MustParse(defaultScheduleFor(loaders))
+		}
+
+		loaders := loaders
+
+		daemon.Jobs = append(daemon.Jobs, scheduler.Job{
+			Name:     loader,
+			Schedule: schedule,
+			Run: func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err := updateOutputs(outputs, catalog, loaders); err != nil {
+					log.Errorf("updating quotes: %s", err)
+				}
+			},
+		})
+
+		log.Infof("[%s] %d securities scheduled at '%s'", loader, len(loaders), schedule)
+	}
+
+	sort.Slice(daemon.Jobs, func(i, j int) bool {
+		return daemon.Jobs[i].Name < daemon.Jobs[j].Name
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		log.Info("shutting down, waiting for the running updates to complete")
+	}()
+
+	return daemon.Run(ctx)
+}
+
+// defaultScheduleFor returns the default schedule of the securities of a loader.
+func defaultScheduleFor(loaders []*security.Security) string {
+	for _, sec := range loaders {
+		if sec.Frequency != quotes.Monthly {
+			return defaultSchedule
+		}
+	}
+	return defaultWeeklySchedule
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/isin"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/enrichman/portfolio-performance/pkg/security/discovery"
+	"github.com/enrichman/portfolio-performance/pkg/security/loaders"
+	"github.com/enrichman/portfolio-performance/pkg/security/loaders/morganstanley"
+)
+
+const discoverUsage = `Usage: portfolio-performance discover <source> [flags] <input>
+
+Sources:
+  morganstanley   list the share classes of a Morgan Stanley fund, given its fund ID or the URL of a fund page
+  mef             propose the Italian government bonds not tracked yet, from the list of the outstanding ones of the MEF
+  mot             propose the bonds not tracked yet, from the list of the Borsa Italiana MOT market
+  etfplus         propose the ETFs not tracked yet, from the list of the Borsa Italiana ETFplus market
+
+Run "portfolio-performance discover <source> -h" for the flags of a source.
+`
+
+func discoverCmd(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, discoverUsage)
+		return errors.New("missing source")
+	}
+
+	if args[0] == "morganstanley" {
+		return discoverMorganStanley(args[1:])
+	}
+
+	source, err := discovery.ParseSource(args[0])
+	if err != nil {
+		fmt.Fprint(os.Stderr, discoverUsage)
+		return err
+	}
+	return discoverListing(source, args[1:])
+}
+
+// isinFlags collects the repeated "-isin shareClass=ISIN" flags.
+type isinFlags map[string]string
+
+func (i isinFlags) String() string {
+	pairs := []string{}
+	for shareClass, code := range i {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", shareClass, code))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (i isinFlags) Set(value string) error {
+	shareClass, code, found := strings.Cut(value, "=")
+	if !found || !isin.Valid(code) {
+		return fmt.Errorf("wrong ISIN \"%s\" - should be \"shareClass=ISIN\", with a valid ISIN", value)
+	}
+	i[shareClass] = code
+	return nil
+}
+
+func discoverMorganStanley(args []string) error {
+	isins := isinFlags{}
+
+	flags := flag.NewFlagSet("discover morganstanley", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "securities.csv", "catalog file to append the share classes to")
+	classes := flags.String("class", "", "comma separated share classes to emit the catalog rows of, i.e. \"A,AX\"")
+	name := flags.String("name", "", "name of the fund, followed by the share class in the name of the rows (required with -class)")
+	options := flags.String("options", "tags=morganstanley", "options of the rows, in the \"key=value;key=value\" format")
+	flags.Var(isins, "isin", "ISIN of a share class, i.e. \"A=LU0119620416\" (repeatable). Read from the share class page if the input is a URL")
+	write := flags.Bool("write", false, "append the rows to the catalog, instead of only printing them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: portfolio-performance discover morganstanley [flags] <FUNDID or URL>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing fund ID or URL")
+	}
+	input := strings.TrimSpace(flags.Arg(0))
+
+	fundID, shareClasses, err := morganstanley.Discover(input)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SHARE CLASS\tCURRENCY\tLATEST NAV\n")
+	for _, s := range shareClasses {
+		navs := []string{}
+		for _, nav := range s.NAVs {
+			navs = append(navs, fmt.Sprintf("%s %g (%s)", nav.Currency, nav.Value, nav.Date.Format(time.DateOnly)))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.ID, s.BaseCurrency, strings.Join(navs, ", "))
+	}
+	w.Flush()
+
+	if *classes == "" {
+		log.Infof("%d share classes found for fund %s - choose the ones to add with -class", len(shareClasses), fundID)
+		return nil
+	}
+	if *name == "" {
+		return errors.New("missing name - set it with -name")
+	}
+
+	records := [][]string{}
+	for _, id := range strings.Split(*classes, ",") {
+		id = strings.TrimSpace(id)
+
+		var shareClass *morganstanley.ShareClass
+		for i := range shareClasses {
+			if shareClasses[i].ID == id {
+				shareClass = &shareClasses[i]
+			}
+		}
+		if shareClass == nil {
+			return fmt.Errorf("share class '%s' not found in fund %s", id, fundID)
+		}
+		// the loader fetches only the NAVs in EUR
+		if !shareClass.Loadable() {
+			return fmt.Errorf("share class '%s' has no NAVs in EUR", id)
+		}
+
+		code, err := shareClassISIN(isins, input, id)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("%s.%s.%s", code, fundID, id)
+		records = append(records, []string{key, *name + " " + id, "morganstanley", *options})
+	}
+
+	return appendToCatalog(*catalogPath, records, *write)
+}
+
+// shareClassISIN returns the ISIN of a share class, set with the flags or read from the page of the share class.
+func shareClassISIN(isins isinFlags, input, shareClassID string) (string, error) {
+	if code, found := isins[shareClassID]; found {
+		return code, nil
+	}
+
+	pageURL, err := morganstanley.ShareClassURL(input, shareClassID)
+	if err != nil {
+		return "", fmt.Errorf("ISIN of share class '%s' not set with -isin, and %w", shareClassID, err)
+	}
+
+	keys, err := loaders.Resolve("morganstanley", pageURL)
+	if err != nil {
+		return "", fmt.Errorf("reading the ISIN of share class '%s': %w", shareClassID, err)
+	}
+	code, _, _ := strings.Cut(keys[0], ".")
+	return code, nil
+}
+
+// ruleFlags collects the repeated "-rule field=value" flags.
+type ruleFlags []discovery.Rule
+
+func (r *ruleFlags) String() string {
+	rules := []string{}
+	for _, rule := range *r {
+		rules = append(rules, rule.Field+"="+rule.Value)
+	}
+	return strings.Join(rules, ", ")
+}
+
+func (r *ruleFlags) Set(value string) error {
+	rule, err := discovery.ParseRule(value)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, rule)
+	return nil
+}
+
+func discoverListing(source discovery.Source, args []string) error {
+	var rules ruleFlags
+
+	flags := flag.NewFlagSet("discover "+string(source), flag.ExitOnError)
+	catalogPath := flags.String("catalog", "securities.csv", "catalog file to append the instruments to")
+	flags.Var(&rules, "rule", "filter on a field of the instruments, i.e. \"type=BTP Italia\" (repeatable). Fields: type, name (regexp), issuer, country, maturity-after, maturity-before")
+	tags := flags.String("tags", "", "comma separated tags added to the ones of the source")
+	write := flags.Bool("write", false, "append the rows to the catalog, instead of only printing them")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: portfolio-performance discover %s [flags] <FILE>\n", source)
+		fmt.Fprintln(flags.Output(), "The FILE is the downloaded CSV or XLSX list of the instruments.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing list file")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("reading list: %w", err)
+	}
+
+	instruments, err := discovery.Read(data)
+	if err != nil {
+		return fmt.Errorf("reading list '%s': %w", flags.Arg(0), err)
+	}
+
+	catalogBytes, err := os.ReadFile(*catalogPath)
+	if err != nil {
+		return fmt.Errorf("reading catalog: %w", err)
+	}
+
+	// the securities registered in the catalog are not worth logging here
+	level := log.GetLevel()
+	log.SetLevel(log.ErrorLevel)
+	catalog, err := security.LoadSecuritiesFromCSV(catalogBytes)
+	log.SetLevel(level)
+	if err != nil {
+		return fmt.Errorf("loading securities from CSV: %w", err)
+	}
+
+	extraTags := []string{}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			extraTags = append(extraTags, tag)
+		}
+	}
+
+	proposals := discovery.Propose(source, instruments, catalog, rules, extraTags, time.Now())
+	if len(proposals) == 0 {
+		log.Infof("%d instruments read, none to add", len(instruments))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ISIN\tTYPE\tMATURITY\tNAME\n")
+	records := [][]string{}
+	for _, p := range proposals {
+		maturity := ""
+		if p.Instrument.Maturity != nil {
+			maturity = p.Instrument.Maturity.Format(time.DateOnly)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Instrument.ISIN, p.Instrument.Type, maturity, p.Record[1])
+		records = append(records, p.Record)
+	}
+	w.Flush()
+
+	log.Infof("%d instruments read, %d not tracked yet", len(instruments), len(proposals))
+	return appendToCatalog(*catalogPath, records, *write)
+}
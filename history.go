@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/enrichman/portfolio-performance/pkg/store"
+)
+
+func historyCmd(args []string) error {
+	var storeCfg storeConfig
+	var catalogCfg catalogConfig
+
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	asOf := flags.String("as-of", "", "print the series as it was at the given time (YYYY-MM-DD or RFC3339), instead of all the versions of its quotes")
+	storeCfg.register(flags)
+	catalogCfg.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: portfolio-performance history [flags] <ID>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing series ID")
+	}
+	id := flags.Arg(0)
+
+	catalog, err := catalogCfg.load()
+	if err != nil {
+		return err
+	}
+
+	outputs, err := openOutputs(storeCfg, catalogCfg.privateDir, catalog)
+	if err != nil {
+		return err
+	}
+	defer closeOutputs(outputs)
+
+	st := outputOf(outputs, catalog, id).store
+
+	var v any
+	if *asOf == "" {
+		v, err = st.History(id)
+	} else {
+		at, parseErr := store.ParseAsOf(*asOf)
+		if parseErr != nil {
+			return parseErr
+		}
+		v, err = store.AsOf(st, id, at)
+	}
+	if err != nil {
+		return fmt.Errorf("reading series [%s]: %w", id, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
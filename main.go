@@ -2,42 +2,75 @@ package main
 
 import (
 	_ "embed"
+	"fmt"
 	"os"
 	"strings"
-	"sync"
 
 	"github.com/charmbracelet/log"
-	"github.com/enrichman/portfolio-performance/pkg/security"
 )
 
 //go:embed securities.csv
 var securities []byte
 
+const usage = `Usage: portfolio-performance [command] [flags]
+
+Commands:
+  update      fetch the quotes of all the securities and publish them in the out folder (default)
+  serve       serve the published quotes over HTTP
+  daemon      keep running, updating the quotes of each loader on its own schedule
+  history     print the versions of the quotes of a series, or the series as it was at a given time
+  prune       list (or delete) the published series not found in the catalog
+  migrate     move a series to a new ID, publishing it also with the old one for a while
+  add         resolve the parameters of a security from its ISIN or URL, and append it to the catalog
+  discover    list the securities of a provider or of an official list, and append the new ones to the catalog
+  validate    check the catalog for errors, duplicates and conflicting outputs
+  portfolio   link the securities of a Portfolio Performance XML file to the quotes, or list the missing ones
+
+Run "portfolio-performance <command> -h" for the flags of a command.
+`
+
 func main() {
 	if strings.ToLower(os.Getenv("LOG_LEVEL")) == "debug" {
 		log.SetLevel(log.DebugLevel)
 	}
 
-	loaders, err := security.LoadSecuritiesFromCSV(securities)
-	if err != nil {
-		log.Errorf("loading securities from CSV: %s", err)
-		os.Exit(1)
+	command, args := "update", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
 
-	log.Infof("loaded %d securities", len(loaders))
-
-	var wg sync.WaitGroup
+	var err error
 
-	for _, loader := range loaders {
-		wg.Add(1)
-
-		loader := loader
-
-		go func() {
-			defer wg.Done()
-			security.UpdateQuotes(loader)
-		}()
+	switch command {
+	case "update":
+		err = updateCmd(args)
+	case "serve":
+		err = serveCmd(args)
+	case "daemon":
+		err = daemonCmd(args)
+	case "history":
+		err = historyCmd(args)
+	case "prune":
+		err = pruneCmd(args)
+	case "migrate":
+		err = migrateCmd(args)
+	case "add":
+		err = addCmd(args)
+	case "discover":
+		err = discoverCmd(args)
+	case "validate":
+		err = validateCmd(args)
+	case "portfolio":
+		err = portfolioCmd(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
 	}
 
-	wg.Wait()
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
 }
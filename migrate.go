@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+)
+
+func migrateCmd(args []string) error {
+	var storeCfg storeConfig
+	var catalogCfg catalogConfig
+
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "securities.csv", "catalog file to record the new ID in, the embedded one or an overlay")
+	days := flags.Int("days", 180, "days the old ID is still published as an alias, 0 to keep it forever")
+	write := flags.Bool("write", false, "move the series and update the catalog, instead of only describing the changes")
+	storeCfg.register(flags)
+	catalogCfg.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: portfolio-performance migrate [flags] <OLD_ID> <NEW_ID>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("missing old or new ID")
+	}
+	oldID, newID := flags.Arg(0), flags.Arg(1)
+
+	if *days < 0 {
+		return fmt.Errorf("wrong days \"%d\" - should be a positive number or 0", *days)
+	}
+
+	catalogBytes, err := os.ReadFile(*catalogPath)
+	if err != nil {
+		return fmt.Errorf("reading catalog: %w", err)
+	}
+
+	catalog, err := catalogCfg.loadReplacing(*catalogPath, catalogBytes)
+	if err != nil {
+		return err
+	}
+
+	var sec *security.Security
+	for _, s := range catalog {
+		if s.ID() == oldID {
+			sec = s
+		}
+	}
+	if sec == nil {
+		return fmt.Errorf("no security with ID '%s' in the catalog", oldID)
+	}
+
+	alias := security.Alias{ID: oldID}
+	if *days > 0 {
+		until := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, *days)
+		alias.Until = &until
+	}
+
+	var key string
+	for _, identifier := range sec.Identifiers {
+		if identifier.Type == security.ProviderIdentifier {
+			key = identifier.Value
+		}
+	}
+
+	newCatalogBytes, err := security.RenameInCatalog(catalogBytes, key, newID, alias)
+	if err != nil {
+		return fmt.Errorf("updating catalog: %w", err)
+	}
+
+	// the catalog is loaded again, to check the new ID is valid and not used by another security
+	newCatalog, err := catalogCfg.loadReplacing(*catalogPath, newCatalogBytes)
+	if err != nil {
+		return err
+	}
+
+	var renamed *security.Security
+	for _, s := range newCatalog {
+		if s.ID() == newID && len(s.Aliases) > 0 {
+			renamed = s
+		}
+	}
+	if renamed == nil {
+		return fmt.Errorf("security '%s' cannot be renamed to '%s' - see the errors above", oldID, newID)
+	}
+
+	outputs, err := openOutputs(storeCfg, catalogCfg.privateDir, catalog)
+	if err != nil {
+		return err
+	}
+	defer closeOutputs(outputs)
+
+	out := outputOf(outputs, catalog, oldID)
+
+	if !*write {
+		log.Infof("series '%s' to move to '%s', still published as '%s' %s - run with -write to migrate it", oldID, newID, oldID, aliasPeriod(alias))
+		return nil
+	}
+
+	migrated, err := security.Migrate(out.dir, out.store, oldID, newID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*catalogPath, newCatalogBytes, 0644); err != nil {
+		return fmt.Errorf("writing catalog: %w", err)
+	}
+
+	if err := security.WriteAliases(out.dir, out.store, []*security.Security{renamed}, time.Now()); err != nil {
+		return err
+	}
+
+	health, err := security.LoadHealth(out.dir)
+	if err != nil {
+		return fmt.Errorf("loading health: %w", err)
+	}
+	health.Remove(oldID)
+	if err := security.WriteHealth(out.dir, health); err != nil {
+		return fmt.Errorf("writing health: %w", err)
+	}
+
+	log.Infof("series '%s' moved to '%s' [%d quotes], still published as '%s' %s", oldID, newID, migrated, oldID, aliasPeriod(alias))
+	if !catalogCfg.isOverlay(*catalogPath) {
+		log.Infof("catalog '%s' updated - rebuild the binary to publish the new ID", *catalogPath)
+	}
+	return nil
+}
+
+// aliasPeriod describes the deprecation period of an Alias.
+func aliasPeriod(alias security.Alias) string {
+	if alias.Until == nil {
+		return "forever"
+	}
+	return "until " + alias.Until.Format(time.DateOnly)
+}
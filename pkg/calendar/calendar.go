@@ -0,0 +1,115 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Calendar of the business days of an exchange or settlement system.
+type Calendar struct {
+	name     string
+	holidays func(year int) []time.Time
+}
+
+var (
+	// Weekdays has no holidays besides the weekends.
+	Weekdays = &Calendar{name: "weekdays", holidays: func(int) []time.Time { return nil }}
+	// TARGET2 is the calendar of the Eurosystem settlement system.
+	TARGET2 = &Calendar{name: "target2", holidays: target2Holidays}
+	// BorsaItaliana is the calendar of the Italian stock exchange.
+	BorsaItaliana = &Calendar{name: "borsaitaliana", holidays: borsaItalianaHolidays}
+	// NYSE is the calendar of the New York Stock Exchange.
+	NYSE = &Calendar{name: "nyse", holidays: nyseHolidays}
+	// LSE is the calendar of the London Stock Exchange.
+	LSE = &Calendar{name: "lse", holidays: lseHolidays}
+
+	calendars = []*Calendar{Weekdays, TARGET2, BorsaItaliana, NYSE, LSE}
+)
+
+// Get returns the Calendar with the given name.
+func Get(name string) (*Calendar, error) {
+	names := []string{}
+	for _, c := range calendars {
+		if c.name == strings.ToLower(name) {
+			return c, nil
+		}
+		names = append(names, c.name)
+	}
+	return nil, fmt.Errorf("unknown calendar \"%s\" - should be one of %s", name, strings.Join(names, ", "))
+}
+
+// Name returns the Calendar name.
+func (c *Calendar) Name() string {
+	return c.name
+}
+
+// Holidays returns the weekday holidays of the year, sorted by date.
+func (c *Calendar) Holidays(year int) []time.Time {
+	holidays := []time.Time{}
+	for _, h := range c.holidays(year) {
+		if !isWeekend(h) {
+			holidays = append(holidays, h)
+		}
+	}
+
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Before(holidays[j])
+	})
+
+	return holidays
+}
+
+// IsBusinessDay reports whether the date is neither a weekend nor a holiday.
+func (c *Calendar) IsBusinessDay(date time.Time) bool {
+	if isWeekend(date) {
+		return false
+	}
+
+	for _, h := range c.holidays(date.Year()) {
+		if h.Month() == date.Month() && h.Day() == date.Day() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PreviousBusinessDay returns the date if it is a business day, or the business day before it.
+func (c *Calendar) PreviousBusinessDay(date time.Time) time.Time {
+	for !c.IsBusinessDay(date) {
+		date = date.AddDate(0, 0, -1)
+	}
+	return date
+}
+
+// NextBusinessDay returns the date if it is a business day, or the business day after it.
+func (c *Calendar) NextBusinessDay(date time.Time) time.Time {
+	for !c.IsBusinessDay(date) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// BusinessDaysBetween returns the number of business days in the (from, to] interval.
+func (c *Calendar) BusinessDaysBetween(from, to time.Time) int {
+	from, to = day(from), day(to)
+
+	count := 0
+	for d := from.AddDate(0, 0, 1); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if c.IsBusinessDay(d) {
+			count++
+		}
+	}
+	return count
+}
+
+func isWeekend(date time.Time) bool {
+	return date.Weekday() == time.Saturday || date.Weekday() == time.Sunday
+}
+
+func day(date time.Time) time.Time {
+	date = date.UTC()
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,62 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEasterSunday(t *testing.T) {
+	assert.Equal(t, date(2019, time.April, 21), EasterSunday(2019))
+	assert.Equal(t, date(2024, time.March, 31), EasterSunday(2024))
+	assert.Equal(t, date(2025, time.April, 20), EasterSunday(2025))
+	assert.Equal(t, date(2038, time.April, 25), EasterSunday(2038))
+}
+
+func TestGet(t *testing.T) {
+	cal, err := Get("TARGET2")
+	require.Nil(t, err)
+	assert.Equal(t, TARGET2, cal)
+
+	_, err = Get("unknown")
+	require.NotNil(t, err)
+}
+
+func TestIsBusinessDay(t *testing.T) {
+	tt := []struct {
+		calendar *Calendar
+		date     time.Time
+		expected bool
+	}{
+		{BorsaItaliana, date(2024, time.March, 29), false}, // Good Friday
+		{BorsaItaliana, date(2024, time.April, 1), false},  // Easter Monday
+		{BorsaItaliana, date(2024, time.August, 15), false},
+		{BorsaItaliana, date(2024, time.August, 16), true},
+		{BorsaItaliana, date(2024, time.August, 17), false}, // Saturday
+		{TARGET2, date(2024, time.August, 15), true},
+		{TARGET2, date(2024, time.December, 26), false},
+		{NYSE, date(2024, time.November, 28), false}, // Thanksgiving
+		{NYSE, date(2024, time.April, 1), true},
+		{NYSE, date(2021, time.July, 5), false},      // Independence Day observed
+		{NYSE, date(2021, time.December, 31), true},  // New Year's Day on Saturday is not observed
+		{NYSE, date(2023, time.June, 19), false},     // Juneteenth
+		{LSE, date(2024, time.May, 6), false},        // Early May bank holiday
+		{LSE, date(2024, time.May, 27), false},       // Spring bank holiday
+		{LSE, date(2021, time.December, 28), false},  // Boxing Day substitute
+		{LSE, date(2022, time.September, 19), false}, // state funeral
+		{Weekdays, date(2024, time.December, 25), true},
+	}
+
+	for _, tc := range tt {
+		assert.Equal(t, tc.expected, tc.calendar.IsBusinessDay(tc.date), "%s %s", tc.calendar.Name(), tc.date.Format(time.DateOnly))
+	}
+}
+
+func TestBusinessDays(t *testing.T) {
+	assert.Equal(t, date(2024, time.March, 28), BorsaItaliana.PreviousBusinessDay(date(2024, time.April, 1)))
+	assert.Equal(t, date(2024, time.April, 2), BorsaItaliana.NextBusinessDay(date(2024, time.March, 29)))
+	assert.Equal(t, 1, BorsaItaliana.BusinessDaysBetween(date(2024, time.March, 28), date(2024, time.April, 2)))
+	assert.Len(t, TARGET2.Holidays(2024), 6)
+}
@@ -0,0 +1,155 @@
+package calendar
+
+import "time"
+
+func target2Holidays(year int) []time.Time {
+	easter := EasterSunday(year)
+
+	return []time.Time{
+		date(year, time.January, 1),
+		easter.AddDate(0, 0, -2), // Good Friday
+		easter.AddDate(0, 0, 1),  // Easter Monday
+		date(year, time.May, 1),
+		date(year, time.December, 25),
+		date(year, time.December, 26),
+	}
+}
+
+func borsaItalianaHolidays(year int) []time.Time {
+	return append(target2Holidays(year),
+		date(year, time.August, 15),
+		date(year, time.December, 24),
+		date(year, time.December, 31),
+	)
+}
+
+func nyseHolidays(year int) []time.Time {
+	holidays := []time.Time{
+		nthWeekday(year, time.January, time.Monday, 3),    // Martin Luther King Jr. Day
+		nthWeekday(year, time.February, time.Monday, 3),   // Presidents' Day
+		EasterSunday(year).AddDate(0, 0, -2),              // Good Friday
+		lastWeekday(year, time.May, time.Monday),          // Memorial Day
+		observed(date(year, time.July, 4)),                // Independence Day
+		nthWeekday(year, time.September, time.Monday, 1),  // Labor Day
+		nthWeekday(year, time.November, time.Thursday, 4), // Thanksgiving Day
+		observed(date(year, time.December, 25)),
+	}
+
+	// New Year's Day is not moved to the previous year when it falls on a Saturday
+	if newYear := date(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		holidays = append(holidays, observed(newYear))
+	}
+	if year >= 2022 {
+		holidays = append(holidays, observed(date(year, time.June, 19))) // Juneteenth
+	}
+
+	return holidays
+}
+
+func lseHolidays(year int) []time.Time {
+	easter := EasterSunday(year)
+
+	holidays := []time.Time{
+		substitute(date(year, time.January, 1)),
+		easter.AddDate(0, 0, -2),                    // Good Friday
+		easter.AddDate(0, 0, 1),                     // Easter Monday
+		lastWeekday(year, time.August, time.Monday), // Summer bank holiday
+	}
+
+	// Christmas and Boxing Day are moved to the following weekdays when they fall on a weekend
+	christmas := date(year, time.December, 25)
+	switch christmas.Weekday() {
+	case time.Friday:
+		holidays = append(holidays, christmas, date(year, time.December, 28))
+	case time.Saturday:
+		holidays = append(holidays, date(year, time.December, 27), date(year, time.December, 28))
+	case time.Sunday:
+		holidays = append(holidays, date(year, time.December, 26), date(year, time.December, 27))
+	default:
+		holidays = append(holidays, christmas, date(year, time.December, 26))
+	}
+
+	// the May bank holidays were moved for the VE day and jubilee celebrations
+	switch year {
+	case 2020:
+		holidays = append(holidays, date(2020, time.May, 8), lastWeekday(year, time.May, time.Monday))
+	case 2012:
+		holidays = append(holidays, nthWeekday(year, time.May, time.Monday, 1), date(2012, time.June, 4), date(2012, time.June, 5))
+	case 2022:
+		holidays = append(holidays, nthWeekday(year, time.May, time.Monday, 1), date(2022, time.June, 2), date(2022, time.June, 3))
+	default:
+		holidays = append(holidays, nthWeekday(year, time.May, time.Monday, 1), lastWeekday(year, time.May, time.Monday))
+	}
+
+	// one-off closures
+	switch year {
+	case 2011:
+		holidays = append(holidays, date(2011, time.April, 29)) // royal wedding
+	case 2022:
+		holidays = append(holidays, date(2022, time.September, 19)) // state funeral of Queen Elizabeth II
+	case 2023:
+		holidays = append(holidays, date(2023, time.May, 8)) // coronation of King Charles III
+	}
+
+	return holidays
+}
+
+// EasterSunday computes the Gregorian Easter date with the anonymous (Meeus/Jones/Butcher) algorithm.
+func EasterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return date(year, time.Month(month), day)
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// nthWeekday returns the n-th weekday of the month.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := date(year, month, 1)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last weekday of the month.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := date(year, month+1, 0)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// observed moves a Saturday holiday to Friday and a Sunday holiday to Monday.
+func observed(holiday time.Time) time.Time {
+	switch holiday.Weekday() {
+	case time.Saturday:
+		return holiday.AddDate(0, 0, -1)
+	case time.Sunday:
+		return holiday.AddDate(0, 0, 1)
+	}
+	return holiday
+}
+
+// substitute moves a weekend holiday to the following Monday.
+func substitute(holiday time.Time) time.Time {
+	switch holiday.Weekday() {
+	case time.Saturday:
+		return holiday.AddDate(0, 0, 2)
+	case time.Sunday:
+		return holiday.AddDate(0, 0, 1)
+	}
+	return holiday
+}
@@ -0,0 +1,64 @@
+// Package isin validates the International Securities Identification Numbers.
+package isin
+
+import (
+	"regexp"
+)
+
+// pattern matches the format of an ISIN: the country code, the national code and the check digit.
+var pattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{9}[0-9]$`)
+
+// searchPattern finds the codes with the format of an ISIN in a text.
+var searchPattern = regexp.MustCompile(`\b[A-Z]{2}[A-Z0-9]{9}[0-9]`)
+
+// Match reports if s has the format of an ISIN, without verifying its check digit.
+func Match(s string) bool {
+	return pattern.MatchString(s)
+}
+
+// Find returns the first ISIN with the right check digit found in s, i.e. in the URL of a page, or "" if none.
+func Find(s string) string {
+	for _, code := range searchPattern.FindAllString(s, -1) {
+		if Valid(code) {
+			return code
+		}
+	}
+	return ""
+}
+
+// Valid reports if s is an ISIN with the right check digit.
+func Valid(s string) bool {
+	if !Match(s) {
+		return false
+	}
+	return CheckDigit(s[:11]) == int(s[11]-'0')
+}
+
+// CheckDigit returns the check digit of the first 11 characters of an ISIN, computed with the Luhn algorithm
+// on the digits of the code, where the letters are converted to numbers (A=10 ... Z=35).
+func CheckDigit(code string) int {
+	digits := []int{}
+	for _, c := range code {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, int(c-'0'))
+		case c >= 'A' && c <= 'Z':
+			n := int(c-'A') + 10
+			digits = append(digits, n/10, n%10)
+		}
+	}
+
+	// starting from the rightmost digit, every other digit is doubled
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
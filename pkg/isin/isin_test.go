@@ -0,0 +1,26 @@
+package isin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	for _, s := range []string{"IT0005547408", "AT0000A324S8", "IE00B4L5Y983", "LU0119620416", "US0378331005"} {
+		assert.True(t, Valid(s), s)
+	}
+
+	// wrong check digit, wrong format
+	for _, s := range []string{"IT0005547409", "QS0000003560", "it0005547408", "IT000554740", "FP-FonTe-Dinamico"} {
+		assert.False(t, Valid(s), s)
+	}
+
+	assert.True(t, Match("QS0000003560"))
+}
+
+func TestFind(t *testing.T) {
+	assert.Equal(t, "IT0001083424", Find("https://www.fondidoc.it/d/Index/SAZINTE/IT0001083424_eurizon-azionario-internazionale-etico"))
+	assert.Equal(t, "IE00B4L5Y983", Find("https://markets.ft.com/data/etfs/tearsheet/summary?s=IE00B4L5Y983:EUR"))
+	assert.Equal(t, "", Find("https://www.borsaitaliana.it/borsa/obbligazioni/mot/btp/scheda/IT0005547409.html"))
+}
@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+)
+
+// Rule selects what a run has to be notified for.
+type Rule string
+
+const (
+	// NewFailures notifies the series that started failing in the run.
+	NewFailures Rule = "failures"
+	// NewStale notifies the series that became stale in the run.
+	NewStale Rule = "stale"
+	// Revisions notifies the published quotes that changed value.
+	Revisions Rule = "revisions"
+)
+
+// ParseRules parses a comma separated list of Rules.
+func ParseRules(s string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, r := range strings.Split(s, ",") {
+		switch rule := Rule(strings.TrimSpace(r)); rule {
+		case NewFailures, NewStale, Revisions:
+			rules = append(rules, rule)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown rule \"%s\" - should be one of failures, stale, revisions", r)
+		}
+	}
+	return rules, nil
+}
+
+// Sink delivers a Notification.
+type Sink interface {
+	Name() string
+	Send(n Notification) error
+}
+
+// SeriesRevisions are the revised quotes of a series.
+type SeriesRevisions struct {
+	ID        string              `json:"id"`
+	Revisions []security.Revision `json:"revisions"`
+}
+
+// Notification of the results of a run.
+type Notification struct {
+	Title     string            `json:"title"`
+	Time      time.Time         `json:"time"`
+	Failures  []security.Alert  `json:"failures,omitempty"`
+	Stale     []security.Alert  `json:"stale,omitempty"`
+	Revisions []SeriesRevisions `json:"revisions,omitempty"`
+}
+
+// Empty reports whether there is nothing to notify.
+func (n Notification) Empty() bool {
+	return len(n.Failures) == 0 && len(n.Stale) == 0 && len(n.Revisions) == 0
+}
+
+// Markdown renders the Notification, ready to be used as the body of a GitHub issue.
+func (n Notification) Markdown() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s\n\nRun of %s.\n", n.Title, n.Time.Format(time.RFC1123))
+
+	if len(n.Failures) > 0 {
+		sb.WriteString("\n## Failures\n\n| Security | Error | Since |\n| --- | --- | --- |\n")
+		for _, a := range n.Failures {
+			fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", a.ID, a.Message, a.Since.Format(time.DateOnly))
+		}
+	}
+
+	if len(n.Stale) > 0 {
+		sb.WriteString("\n## Stale series\n\n| Security | Message | Since |\n| --- | --- | --- |\n")
+		for _, a := range n.Stale {
+			fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", a.ID, a.Message, a.Since.Format(time.DateOnly))
+		}
+	}
+
+	if len(n.Revisions) > 0 {
+		sb.WriteString("\n## Revised quotes\n\n| Security | Date | Old | New |\n| --- | --- | --- | --- |\n")
+		for _, s := range n.Revisions {
+			for _, r := range s.Revisions {
+				fmt.Fprintf(&sb, "| `%s` | %s | %v | %v |\n", s.ID, r.Date.Format(time.DateOnly), r.Old, r.New)
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// Notifier sends the results of a run to its Sinks, according to its Rules.
+type Notifier struct {
+	Rules []Rule
+	Sinks []Sink
+}
+
+// Build creates the Notification of a run.
+func (n *Notifier) Build(report security.Report, health security.Health) Notification {
+	notification := Notification{
+		Title: "Portfolio Performance quotes update",
+		Time:  report.End,
+	}
+
+	for _, rule := range n.Rules {
+		switch rule {
+		case NewFailures:
+			for _, a := range health.Alerts {
+				if a.Kind == security.FailureAlert && a.New {
+					notification.Failures = append(notification.Failures, a)
+				}
+			}
+		case NewStale:
+			for _, a := range health.Alerts {
+				if a.Kind == security.StaleAlert && a.New {
+					notification.Stale = append(notification.Stale, a)
+				}
+			}
+		case Revisions:
+			for _, r := range report.Results {
+				if len(r.Revisions) > 0 {
+					notification.Revisions = append(notification.Revisions, SeriesRevisions{ID: r.ID, Revisions: r.Revisions})
+				}
+			}
+		}
+	}
+
+	return notification
+}
+
+// Notify sends the Notification of the run to all the Sinks, if there is anything to notify.
+func (n *Notifier) Notify(report security.Report, health security.Health) error {
+	notification := n.Build(report, health)
+	if notification.Empty() {
+		log.Debug("nothing to notify")
+		return nil
+	}
+
+	var errs []error
+	for _, sink := range n.Sinks {
+		if err := sink.Send(notification); err != nil {
+			errs = append(errs, fmt.Errorf("sending notification to %s: %w", sink.Name(), err))
+			continue
+		}
+		log.Infof("notification sent to %s", sink.Name())
+	}
+
+	return errors.Join(errs...)
+}
+
+// FromEnv creates a Notifier configured with the NOTIFY_* environment variables.
+// It returns nil if no Sink is configured.
+func FromEnv() (*Notifier, error) {
+	rules := os.Getenv("NOTIFY_RULES")
+	if rules == "" {
+		rules = "failures,stale"
+	}
+
+	parsedRules, err := ParseRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier := &Notifier{Rules: parsedRules}
+
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		notifier.Sinks = append(notifier.Sinks, &WebhookSink{URL: url})
+	}
+
+	if addr := os.Getenv("NOTIFY_SMTP_ADDR"); addr != "" {
+		to := strings.Split(os.Getenv("NOTIFY_SMTP_TO"), ",")
+		if os.Getenv("NOTIFY_SMTP_FROM") == "" || to[0] == "" {
+			return nil, errors.New("NOTIFY_SMTP_FROM and NOTIFY_SMTP_TO are required with NOTIFY_SMTP_ADDR")
+		}
+
+		notifier.Sinks = append(notifier.Sinks, &SMTPSink{
+			Addr:     addr,
+			From:     os.Getenv("NOTIFY_SMTP_FROM"),
+			To:       to,
+			Username: os.Getenv("NOTIFY_SMTP_USERNAME"),
+			Password: os.Getenv("NOTIFY_SMTP_PASSWORD"),
+		})
+	}
+
+	if path := os.Getenv("NOTIFY_MARKDOWN_FILE"); path != "" {
+		notifier.Sinks = append(notifier.Sinks, &MarkdownSink{Path: path})
+	}
+
+	if len(notifier.Sinks) == 0 {
+		return nil, nil
+	}
+
+	return notifier, nil
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testTime   = time.Date(2023, time.January, 27, 6, 0, 0, 0, time.UTC)
+	testReport = security.Report{
+		End: testTime,
+		Results: []security.Result{
+			{ID: "ISIN1", Revisions: []security.Revision{{Date: testTime, Old: 100, New: 101}}},
+			{ID: "ISIN2", Error: "no quotes found"},
+		},
+	}
+	testHealth = security.Health{
+		Alerts: []security.Alert{
+			{ID: "ISIN2", Kind: security.FailureAlert, Message: "no quotes found", Since: testTime, New: true},
+			{ID: "ISIN3", Kind: security.StaleAlert, Message: "no new quotes", Since: testTime},
+		},
+	}
+)
+
+func TestBuild(t *testing.T) {
+	notifier := &Notifier{Rules: []Rule{NewFailures, NewStale}}
+
+	notification := notifier.Build(testReport, testHealth)
+	require.Len(t, notification.Failures, 1)
+	assert.Equal(t, "ISIN2", notification.Failures[0].ID)
+	assert.Empty(t, notification.Stale)
+	assert.Empty(t, notification.Revisions)
+
+	notifier.Rules = []Rule{Revisions}
+	notification = notifier.Build(testReport, testHealth)
+	require.Len(t, notification.Revisions, 1)
+	assert.Equal(t, "ISIN1", notification.Revisions[0].ID)
+	assert.Contains(t, notification.Markdown(), "| `ISIN1` | 2023-01-27 | 100 | 101 |")
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received Notification
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	notifier := &Notifier{Rules: []Rule{NewFailures}, Sinks: []Sink{&WebhookSink{URL: server.URL}}}
+	require.Nil(t, notifier.Notify(testReport, testHealth))
+
+	require.Len(t, received.Failures, 1)
+	assert.Equal(t, "ISIN2", received.Failures[0].ID)
+}
+
+func TestMarkdownSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notification.md")
+
+	notifier := &Notifier{Rules: []Rule{NewFailures}, Sinks: []Sink{&MarkdownSink{Path: path}}}
+	require.Nil(t, notifier.Notify(testReport, testHealth))
+
+	b, err := os.ReadFile(path)
+	require.Nil(t, err)
+	assert.Contains(t, string(b), "| `ISIN2` | no quotes found | 2023-01-27 |")
+}
+
+// startSMTPServer starts a minimal local SMTP stand-in, returning its address and the received messages.
+func startSMTPServer(t *testing.T) (string, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+
+		var data strings.Builder
+		inData := false
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					messages <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), messages
+}
+
+func TestSMTPSink(t *testing.T) {
+	addr, messages := startSMTPServer(t)
+
+	sink := &SMTPSink{Addr: addr, From: "from@example.com", To: []string{"to@example.com"}}
+	notifier := &Notifier{Rules: []Rule{NewFailures}, Sinks: []Sink{sink}}
+	require.Nil(t, notifier.Notify(testReport, testHealth))
+
+	select {
+	case msg := <-messages:
+		assert.Contains(t, msg, "Subject: Portfolio Performance quotes update")
+		assert.Contains(t, msg, "| `ISIN2` | no quotes found | 2023-01-27 |")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestNotifyNothing(t *testing.T) {
+	notifier := &Notifier{Rules: []Rule{NewStale}, Sinks: []Sink{&WebhookSink{URL: "http://127.0.0.1:0"}}}
+	require.Nil(t, notifier.Notify(testReport, testHealth))
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// WebhookSink posts the Notification as JSON to a URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// Name returns the Sink name.
+func (w *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Send posts the Notification.
+func (w *WebhookSink) Send(n Notification) error {
+	payloadBytes, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("error marshaling request body: %w", err)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	res, err := client.Post(w.URL, "application/json", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("error during post request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("error from request: status_code %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// SMTPSink sends the Notification by email.
+type SMTPSink struct {
+	// Addr is the "host:port" of the SMTP server.
+	Addr     string
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+// Name returns the Sink name.
+func (s *SMTPSink) Name() string {
+	return "smtp"
+}
+
+// Send sends the Notification as a plain text email, with the Markdown body.
+func (s *SMTPSink) Send(n Notification) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("wrong SMTP address \"%s\": %w", s.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", n.Title)
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Markdown(), "\n", "\r\n"))
+
+	err := smtp.SendMail(s.Addr, auth, s.From, s.To, msg.Bytes())
+	if err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+// MarkdownSink writes the Notification in a Markdown file, ready to be opened as a GitHub issue.
+type MarkdownSink struct {
+	Path string
+}
+
+// Name returns the Sink name.
+func (m *MarkdownSink) Name() string {
+	return "markdown"
+}
+
+// Send writes the Notification to the file.
+func (m *MarkdownSink) Send(n Notification) error {
+	err := os.WriteFile(m.Path, []byte(n.Markdown()), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing file [%s]: %w", m.Path, err)
+	}
+	return nil
+}
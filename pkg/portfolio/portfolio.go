@@ -0,0 +1,341 @@
+// Package portfolio reads and edits the XML files of Portfolio Performance.
+package portfolio
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+const (
+	// JSONFeed is the Portfolio Performance feed reading the quotes from a JSON document.
+	JSONFeed = "GENERIC-JSON"
+	// JSONDatePath and JSONClosePath are the JSONPath expressions of the published quotes.
+	JSONDatePath  = "$[*].date"
+	JSONClosePath = "$[*].close"
+
+	// the names of the feed properties of the JSON feed
+	jsonDateProperty  = "GENERIC-JSON-DATE"
+	jsonCloseProperty = "GENERIC-JSON-CLOSE"
+	feedPropertyType  = "FEED"
+
+	// priceFactor is the factor of the price values, stored as integers.
+	priceFactor = 100_000_000
+)
+
+// File is a Portfolio Performance file in the XML format.
+type File struct {
+	document *Node
+	client   *Node
+}
+
+// Security is a security defined in a Portfolio Performance File.
+type Security struct {
+	node *Node
+}
+
+// Open reads the Portfolio Performance File at path.
+func Open(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file [%s]: %s", path, err.Error())
+	}
+
+	file, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file [%s]: %w", path, err)
+	}
+	return file, nil
+}
+
+// Parse parses a Portfolio Performance File.
+func Parse(b []byte) (*File, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("<")) {
+		return nil, errors.New("not a XML file - save the portfolio in Portfolio Performance with \"Save as\" > \"XML\"")
+	}
+
+	document, err := parseXML(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	client := document.Child("client")
+	if client == nil {
+		return nil, errors.New("not a Portfolio Performance file: missing <client> element")
+	}
+
+	return &File{document: document, client: client}, nil
+}
+
+// Save writes the File at path.
+func (f *File) Save(path string) error {
+	var buf bytes.Buffer
+	if err := writeXML(&buf, f.document); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	mode := os.FileMode(0644)
+	if err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	// write to a temporary file and rename it, so the portfolio is never left half written
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), mode); err != nil {
+		return fmt.Errorf("error writing file [%s]: %s", path, err.Error())
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error renaming file [%s]: %s", path, err.Error())
+	}
+	return nil
+}
+
+// Backup copies the file at path to a new file with the current time in its name, returning its path.
+func Backup(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file [%s]: %s", path, err.Error())
+	}
+	defer src.Close()
+
+	backup := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("error creating backup [%s]: %s", backup, err.Error())
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", fmt.Errorf("error writing backup [%s]: %s", backup, err.Error())
+	}
+	if err := dst.Close(); err != nil {
+		return "", fmt.Errorf("error writing backup [%s]: %s", backup, err.Error())
+	}
+
+	return backup, nil
+}
+
+// Securities returns the securities defined in the File.
+func (f *File) Securities() []*Security {
+	securities := []*Security{}
+
+	securitiesNode := f.client.Child("securities")
+	if securitiesNode == nil {
+		return securities
+	}
+
+	for _, node := range securitiesNode.Elements("security") {
+		// the securities of the transactions are references to these ones
+		if node.AttrValue("reference") != "" {
+			continue
+		}
+		securities = append(securities, &Security{node: node})
+	}
+	return securities
+}
+
+// Name returns the name of the Security.
+func (s *Security) Name() string {
+	return s.text("name")
+}
+
+// ISIN returns the ISIN of the Security, if set.
+func (s *Security) ISIN() string {
+	return strings.ToUpper(strings.TrimSpace(s.text("isin")))
+}
+
+// WKN returns the WKN of the Security, if set.
+func (s *Security) WKN() string {
+	return strings.ToUpper(strings.TrimSpace(s.text("wkn")))
+}
+
+// TickerSymbol returns the ticker symbol of the Security, if set.
+func (s *Security) TickerSymbol() string {
+	return strings.ToUpper(strings.TrimSpace(s.text("tickerSymbol")))
+}
+
+// Feed returns the historical quotes feed of the Security.
+func (s *Security) Feed() string {
+	return s.text("feed")
+}
+
+// FeedURL returns the URL of the historical quotes feed of the Security.
+func (s *Security) FeedURL() string {
+	return s.text("feedURL")
+}
+
+// Retired reports if the Security is marked as inactive.
+func (s *Security) Retired() bool {
+	return s.text("isRetired") == "true"
+}
+
+// FeedProperty returns the value of a property of the historical quotes feed.
+func (s *Security) FeedProperty(name string) string {
+	if property := s.feedProperty(name); property != nil {
+		return property.Text()
+	}
+	return ""
+}
+
+// SetJSONFeed sets the historical quotes feed of the Security to the JSON document at url,
+// with the JSONPath expressions of the published quotes.
+func (s *Security) SetJSONFeed(url string) {
+	feed := s.setChild("feed", JSONFeed, s.node.Child("isin"))
+	s.setChild("feedURL", url, feed)
+	s.setFeedProperty(jsonDateProperty, JSONDatePath)
+	s.setFeedProperty(jsonCloseProperty, JSONClosePath)
+}
+
+// HasJSONFeed reports if the historical quotes of the Security are already read from the JSON document at url.
+func (s *Security) HasJSONFeed(url string) bool {
+	return s.Feed() == JSONFeed &&
+		s.FeedURL() == url &&
+		s.FeedProperty(jsonDateProperty) == JSONDatePath &&
+		s.FeedProperty(jsonCloseProperty) == JSONClosePath
+}
+
+// Prices returns the historical prices of the Security, as quotes sorted by date.
+func (s *Security) Prices() ([]quotes.Quote, error) {
+	pricesData := []quotes.Quote{}
+
+	prices := s.node.Child("prices")
+	if prices == nil {
+		return pricesData, nil
+	}
+
+	for _, price := range prices.Elements("price") {
+		date, err := time.Parse(time.DateOnly, price.AttrValue("t"))
+		if err != nil {
+			return nil, fmt.Errorf("wrong price date \"%s\" of %s", price.AttrValue("t"), s.Name())
+		}
+		value, err := strconv.ParseInt(price.AttrValue("v"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("wrong price value \"%s\" of %s", price.AttrValue("v"), s.Name())
+		}
+
+		pricesData = append(pricesData, quotes.Quote{
+			Date:  date,
+			Close: float32(float64(value) / priceFactor),
+		})
+	}
+
+	return pricesData, nil
+}
+
+// SetPrices replaces the historical prices of the Security with the quotes, that have to be sorted by date.
+// Only the day of the quotes is kept: the last quote of every day is used.
+func (s *Security) SetPrices(quotesData []quotes.Quote) {
+	prices := s.node.Child("prices")
+	if prices == nil {
+		prices = &Node{Name: xml.Name{Local: "prices"}}
+		s.node.Insert(prices, s.node.Child("feedURL"))
+	}
+
+	byDay := map[string]int{}
+	priceNodes := []*Node{}
+
+	for _, q := range quotesData {
+		day := q.Date.UTC().Format(time.DateOnly)
+
+		// the float32 close is formatted with its shortest representation, so 185.48 is not stored as 185.47999573
+		value, _ := strconv.ParseFloat(strconv.FormatFloat(float64(q.Close), 'f', -1, 32), 64)
+
+		price := &Node{
+			Name: xml.Name{Local: "price"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "t"}, Value: day},
+				{Name: xml.Name{Local: "v"}, Value: strconv.FormatInt(int64(math.Round(value*priceFactor)), 10)},
+			},
+		}
+
+		if i, found := byDay[day]; found {
+			priceNodes[i] = price
+			continue
+		}
+		byDay[day] = len(priceNodes)
+		priceNodes = append(priceNodes, price)
+	}
+
+	// the prices are indented one level deeper than the Security elements
+	indent := s.node.indent()
+	prices.Children = []any{}
+	for _, price := range priceNodes {
+		prices.Children = append(prices.Children, xml.CharData(indent+"  "), price)
+	}
+	if len(priceNodes) > 0 {
+		prices.Children = append(prices.Children, xml.CharData(indent))
+	}
+}
+
+func (s *Security) text(name string) string {
+	if child := s.node.Child(name); child != nil {
+		return child.Text()
+	}
+	return ""
+}
+
+// setChild sets the text of a child element, adding it after the after element if missing.
+func (s *Security) setChild(name, text string, after *Node) *Node {
+	child := s.node.Child(name)
+	if child == nil {
+		child = &Node{Name: xml.Name{Local: name}}
+		s.node.Insert(child, after)
+	}
+	child.SetText(text)
+	return child
+}
+
+func (s *Security) feedProperty(name string) *Node {
+	properties := s.node.Child("properties")
+	if properties == nil {
+		return nil
+	}
+	for _, property := range properties.Elements("property") {
+		if property.AttrValue("type") == feedPropertyType && property.AttrValue("name") == name {
+			return property
+		}
+	}
+	return nil
+}
+
+func (s *Security) setFeedProperty(name, value string) {
+	if property := s.feedProperty(name); property != nil {
+		property.SetText(value)
+		return
+	}
+
+	properties := s.node.Child("properties")
+	if properties == nil {
+		properties = &Node{Name: xml.Name{Local: "properties"}}
+		s.node.Insert(properties, nil)
+	}
+
+	property := &Node{
+		Name: xml.Name{Local: "property"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "type"}, Value: feedPropertyType},
+			{Name: xml.Name{Local: "name"}, Value: name},
+		},
+	}
+	property.SetText(value)
+
+	if len(properties.Children) == 0 {
+		// a new element is indented one level deeper than its parent
+		indent := s.node.indent()
+		properties.Children = []any{xml.CharData(indent + "  "), property, xml.CharData(indent)}
+		return
+	}
+	properties.Insert(property, nil)
+}
@@ -0,0 +1,142 @@
+package portfolio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	b, err := os.ReadFile("testdata/portfolio.xml")
+	require.Nil(t, err)
+
+	file, err := Parse(b)
+	require.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "portfolio.xml")
+	require.Nil(t, file.Save(path))
+
+	saved, err := os.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, string(b), string(saved))
+}
+
+func TestSetJSONFeed(t *testing.T) {
+	file, err := Open("testdata/portfolio.xml")
+	require.Nil(t, err)
+
+	securities := file.Securities()
+	require.Len(t, securities, 4)
+	assert.Equal(t, "IT0005547408", securities[0].ISIN())
+	assert.Equal(t, "iShares Core MSCI World & Co", securities[1].Name())
+	assert.Equal(t, "YAHOO", securities[1].Feed())
+	assert.Equal(t, "SWDA.MI", securities[1].TickerSymbol())
+	assert.Empty(t, securities[0].TickerSymbol())
+	assert.True(t, securities[2].Retired())
+	assert.Empty(t, securities[3].ISIN())
+
+	url := "https://example.com/json/IT0005547408.json"
+	require.False(t, securities[0].HasJSONFeed(url))
+	securities[0].SetJSONFeed(url)
+	securities[1].SetJSONFeed("https://example.com/json/IE00B4L5Y983.json")
+
+	path := filepath.Join(t.TempDir(), "portfolio.xml")
+	require.Nil(t, file.Save(path))
+
+	file, err = Open(path)
+	require.Nil(t, err)
+
+	securities = file.Securities()
+	assert.True(t, securities[0].HasJSONFeed(url))
+	assert.Equal(t, "SWDA.MI", securities[1].text("tickerSymbol"))
+
+	saved, err := os.ReadFile(path)
+	require.Nil(t, err)
+	assert.True(t, strings.Contains(string(saved), `
+      <isin>IT0005547408</isin>
+      <feed>GENERIC-JSON</feed>
+      <feedURL>https://example.com/json/IT0005547408.json</feedURL>
+      <tickerSymbol/>`))
+	assert.True(t, strings.Contains(string(saved), `
+      <properties>
+        <property type="MARKET" name="MIL">SWDA.MI</property>
+        <property type="FEED" name="GENERIC-JSON-DATE">$[*].date</property>
+        <property type="FEED" name="GENERIC-JSON-CLOSE">$[*].close</property>
+      </properties>`))
+	assert.True(t, strings.Contains(string(saved), `
+      <isRetired>false</isRetired>
+      <properties>
+        <property type="FEED" name="GENERIC-JSON-DATE">$[*].date</property>
+        <property type="FEED" name="GENERIC-JSON-CLOSE">$[*].close</property>
+      </properties>
+    </security>`))
+}
+
+func TestParseNotXML(t *testing.T) {
+	_, err := Parse([]byte("PORTFOLIO\x00\x01"))
+	assert.NotNil(t, err)
+
+	_, err = Parse([]byte("<portfolio/>"))
+	assert.NotNil(t, err)
+}
+
+func TestSetPrices(t *testing.T) {
+	file, err := Open("testdata/portfolio.xml")
+	require.Nil(t, err)
+
+	securities := file.Securities()
+
+	prices, err := securities[0].Prices()
+	require.Nil(t, err)
+	assert.Equal(t, []quotes.Quote{{Date: date("2023-06-12"), Close: 100}}, prices)
+
+	securities[1].SetPrices([]quotes.Quote{
+		{Date: date("2023-01-26"), Close: 185.1},
+		{Date: date("2023-01-27").Add(time.Minute), Close: 185.4},
+		{Date: date("2023-01-27").Add(time.Hour), Close: 185.48},
+	})
+
+	path := filepath.Join(t.TempDir(), "portfolio.xml")
+	require.Nil(t, file.Save(path))
+
+	saved, err := os.ReadFile(path)
+	require.Nil(t, err)
+	assert.True(t, strings.Contains(string(saved), `
+      <prices>
+        <price t="2023-01-26" v="18510000000"/>
+        <price t="2023-01-27" v="18548000000"/>
+      </prices>`))
+
+	file, err = Open(path)
+	require.Nil(t, err)
+
+	prices, err = file.Securities()[1].Prices()
+	require.Nil(t, err)
+	assert.Equal(t, []quotes.Quote{
+		{Date: date("2023-01-26"), Close: 185.1},
+		{Date: date("2023-01-27"), Close: 185.48},
+	}, prices)
+}
+
+func TestBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portfolio.xml")
+	require.Nil(t, os.WriteFile(path, []byte("<client/>"), 0644))
+
+	backup, err := Backup(path)
+	require.Nil(t, err)
+
+	b, err := os.ReadFile(backup)
+	require.Nil(t, err)
+	assert.Equal(t, "<client/>", string(b))
+}
+
+func date(s string) time.Time {
+	d, _ := time.Parse(time.DateOnly, s)
+	return d
+}
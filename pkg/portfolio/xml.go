@@ -0,0 +1,200 @@
+package portfolio
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Node is an element of a XML document. All its content is kept, so that the document is written back
+// unchanged except for the edited elements.
+type Node struct {
+	Name xml.Name
+	Attr []xml.Attr
+	// Children are the *Node, xml.CharData, xml.Comment, xml.ProcInst and xml.Directive in the element.
+	Children []any
+}
+
+// parseXML parses a XML document in a Node, whose children are the top level tokens of the document.
+func parseXML(r io.Reader) (*Node, error) {
+	decoder := xml.NewDecoder(r)
+
+	document := &Node{}
+	stack := []*Node{document}
+
+	for {
+		// the raw tokens keep the namespace prefixes as they are
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing XML: %w", err)
+		}
+
+		parent := stack[len(stack)-1]
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &Node{Name: t.Name, Attr: append([]xml.Attr{}, t.Attr...)}
+			parent.Children = append(parent.Children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 1 || parent.Name != t.Name {
+				return nil, fmt.Errorf("parsing XML: unexpected end element </%s>", qualifiedName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			parent.Children = append(parent.Children, xml.CopyToken(t))
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, errors.New("parsing XML: unexpected end of document")
+	}
+
+	return document, nil
+}
+
+// writeXML writes the document Node.
+func writeXML(w io.Writer, document *Node) error {
+	bw := bufio.NewWriter(w)
+	for _, child := range document.Children {
+		writeToken(bw, child)
+	}
+	return bw.Flush()
+}
+
+func writeToken(w *bufio.Writer, token any) {
+	switch t := token.(type) {
+	case *Node:
+		w.WriteString("<" + qualifiedName(t.Name))
+		for _, attr := range t.Attr {
+			w.WriteString(" " + qualifiedName(attr.Name) + `="` + escape(attr.Value, true) + `"`)
+		}
+		if len(t.Children) == 0 {
+			w.WriteString("/>")
+			return
+		}
+		w.WriteString(">")
+		for _, child := range t.Children {
+			writeToken(w, child)
+		}
+		w.WriteString("</" + qualifiedName(t.Name) + ">")
+	case xml.CharData:
+		w.WriteString(escape(string(t), false))
+	case xml.Comment:
+		w.WriteString("<!--" + string(t) + "-->")
+	case xml.ProcInst:
+		w.WriteString("<?" + t.Target)
+		if len(t.Inst) > 0 {
+			w.WriteString(" " + string(t.Inst))
+		}
+		w.WriteString("?>")
+	case xml.Directive:
+		w.WriteString("<!" + string(t) + ">")
+	}
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// escape escapes the XML special characters, leaving the whitespace of the text as it is.
+func escape(s string, attr bool) string {
+	replacements := []string{"&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;"}
+	if attr {
+		replacements = append(replacements, `"`, "&quot;", "\n", "&#xA;", "\t", "&#x9;")
+	}
+	return strings.NewReplacer(replacements...).Replace(s)
+}
+
+// Child returns the first child element with the given name, or nil.
+func (n *Node) Child(name string) *Node {
+	for _, child := range n.Children {
+		if node, ok := child.(*Node); ok && node.Name.Local == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// Elements returns the child elements with the given name.
+func (n *Node) Elements(name string) []*Node {
+	nodes := []*Node{}
+	for _, child := range n.Children {
+		if node, ok := child.(*Node); ok && node.Name.Local == name {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Text returns the text of the element.
+func (n *Node) Text() string {
+	var sb strings.Builder
+	for _, child := range n.Children {
+		if text, ok := child.(xml.CharData); ok {
+			sb.Write(text)
+		}
+	}
+	return sb.String()
+}
+
+// SetText replaces the content of the element with the text.
+func (n *Node) SetText(text string) {
+	n.Children = []any{xml.CharData(text)}
+}
+
+// AttrValue returns the value of an attribute, or an empty string.
+func (n *Node) AttrValue(name string) string {
+	for _, attr := range n.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// indent returns the whitespace preceding the child elements, or an empty string for an element without them.
+func (n *Node) indent() string {
+	for i, child := range n.Children {
+		if _, ok := child.(*Node); ok && i > 0 {
+			if text, ok := n.Children[i-1].(xml.CharData); ok && strings.TrimSpace(string(text)) == "" {
+				return string(text)
+			}
+		}
+	}
+	return ""
+}
+
+// Insert adds the child element after the after element, or as the last element if after is nil,
+// indented as the other child elements.
+func (n *Node) Insert(child *Node, after *Node) {
+	index := len(n.Children)
+	if index > 0 {
+		// before the whitespace closing the element
+		if text, ok := n.Children[index-1].(xml.CharData); ok && strings.TrimSpace(string(text)) == "" {
+			index--
+		}
+	}
+
+	for i, c := range n.Children {
+		if after != nil && c == any(after) {
+			index = i + 1
+		}
+	}
+
+	inserted := []any{child}
+	if indent := n.indent(); indent != "" {
+		inserted = []any{xml.CharData(indent), child}
+	}
+
+	n.Children = append(n.Children[:index], append(inserted, n.Children[index:]...)...)
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Job is a task run by the Daemon according to its Schedule.
+type Job struct {
+	Name     string
+	Schedule *Schedule
+	// Run is not interrupted on shutdown: the Daemon waits for it to finish.
+	Run func()
+}
+
+// Daemon runs the Jobs on their schedules until its context is done.
+type Daemon struct {
+	Jobs []Job
+	// Jitter is the maximum random delay added to every run.
+	Jitter time.Duration
+	// StatePath is the file the last run of every Job is persisted to.
+	// Missed runs are recovered at startup.
+	StatePath string
+
+	mu      sync.Mutex
+	lastRun map[string]time.Time
+}
+
+// Run starts the Jobs and blocks until the context is done and the running Jobs are finished.
+func (d *Daemon) Run(ctx context.Context) error {
+	err := d.loadState()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+
+	for _, job := range d.Jobs {
+		wg.Add(1)
+
+		job := job
+
+		go func() {
+			defer wg.Done()
+			d.loop(ctx, job)
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (d *Daemon) loop(ctx context.Context, job Job) {
+	for {
+		now := time.Now().In(time.UTC)
+
+		next := job.Schedule.Next(now)
+		if last, found := d.last(job.Name); found && !job.Schedule.Next(last).After(now) {
+			log.Infof("[%s] recovering missed run, last run at %s", job.Name, last.Format(time.RFC3339))
+			next = now
+		}
+		if next.IsZero() {
+			log.Errorf("[%s] schedule '%s' never matches", job.Name, job.Schedule)
+			return
+		}
+
+		wait := next.Sub(now)
+		if d.Jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(d.Jitter)))
+		}
+
+		log.Infof("[%s] next run at %s", job.Name, now.Add(wait).Format(time.RFC3339))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		start := time.Now().In(time.UTC)
+		log.Infof("[%s] running", job.Name)
+		job.Run()
+		log.Infof("[%s] completed in %s", job.Name, time.Since(start))
+
+		err := d.setLast(job.Name, start)
+		if err != nil {
+			log.Errorf("[%s] saving state: %s", job.Name, err)
+		}
+	}
+}
+
+func (d *Daemon) last(name string) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	last, found := d.lastRun[name]
+	return last, found
+}
+
+func (d *Daemon) setLast(name string, t time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastRun[name] = t
+
+	if d.StatePath == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(d.lastRun, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling state: %w", err)
+	}
+
+	tmp := d.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("error writing file [%s]: %w", tmp, err)
+	}
+
+	return os.Rename(tmp, d.StatePath)
+}
+
+func (d *Daemon) loadState() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastRun = map[string]time.Time{}
+
+	if d.StatePath == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(d.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading file [%s]: %w", d.StatePath, err)
+	}
+
+	err = json.Unmarshal(b, &d.lastRun)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling file [%s]: %w", d.StatePath, err)
+	}
+
+	return nil
+}
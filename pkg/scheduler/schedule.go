@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron-like schedule, with the standard five fields:
+// minute, hour, day of month, month and day of week.
+// Each field accepts "*", single values, ranges ("1-5"), lists ("1,15") and steps ("*/15", "1-28/7").
+type Schedule struct {
+	spec     string
+	minutes  []bool
+	hours    []bool
+	days     []bool
+	months   []bool
+	weekdays []bool
+
+	// anyDay and anyWeekday are set when the fields are "*":
+	// as in cron, if both are restricted a day matches if either of them matches
+	anyDay     bool
+	anyWeekday bool
+}
+
+// Parse parses a cron-like Schedule.
+func Parse(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("wrong schedule \"%s\": expected 5 fields, found %d", spec, len(fields))
+	}
+
+	s := &Schedule{
+		spec:       spec,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	var err error
+	parsers := []struct {
+		field    *[]bool
+		min, max int
+	}{
+		{&s.minutes, 0, 59},
+		{&s.hours, 0, 23},
+		{&s.days, 1, 31},
+		{&s.months, 1, 12},
+		{&s.weekdays, 0, 7},
+	}
+
+	for i, p := range parsers {
+		*p.field, err = parseField(fields[i], p.min, p.max)
+		if err != nil {
+			return nil, fmt.Errorf("wrong schedule \"%s\": %w", spec, err)
+		}
+	}
+
+	// 7 is Sunday as well
+	if s.weekdays[7] {
+		s.weekdays[0] = true
+	}
+
+	return s, nil
+}
+
+// MustParse is like Parse, but panics if the spec is wrong.
+func MustParse(spec string) *Schedule {
+	s, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the Schedule spec.
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time matching the Schedule after t, truncated to the minute.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// a matching time is always found within a few years (i.e. February 29th)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.months[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	day, weekday := s.days[t.Day()], s.weekdays[t.Weekday()]
+
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+func parseField(field string, min, max int) ([]bool, error) {
+	values := make([]bool, max+1)
+
+	for _, part := range strings.Split(field, ",") {
+		rng, stepString, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepString)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("wrong step \"%s\"", stepString)
+			}
+		}
+
+		start, end := min, max
+		if rng != "*" {
+			startString, endString, isRange := strings.Cut(rng, "-")
+
+			var err error
+			start, err = strconv.Atoi(startString)
+			if err != nil {
+				return nil, fmt.Errorf("wrong value \"%s\"", startString)
+			}
+
+			end = start
+			if isRange {
+				end, err = strconv.Atoi(endString)
+				if err != nil {
+					return nil, fmt.Errorf("wrong value \"%s\"", endString)
+				}
+			} else if hasStep {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("value \"%s\" out of range [%d-%d]", rng, min, max)
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
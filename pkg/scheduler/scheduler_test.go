@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	_, err := Parse("0 19 * * 1-5")
+	require.Nil(t, err)
+
+	for _, spec := range []string{"0 19 * *", "60 * * * *", "* * * * 1-8", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := Parse(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Friday
+	now := time.Date(2024, time.March, 29, 19, 30, 0, 0, time.UTC)
+
+	tt := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.March, 29, 19, 31, 0, 0, time.UTC)},
+		{"0 19 * * 1-5", time.Date(2024, time.April, 1, 19, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.March, 29, 19, 45, 0, 0, time.UTC)},
+		{"0 7 1 * *", time.Date(2024, time.April, 1, 7, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 7 * * 0", time.Date(2024, time.March, 31, 7, 0, 0, 0, time.UTC)},
+		{"0 7 * * 7", time.Date(2024, time.March, 31, 7, 0, 0, 0, time.UTC)},
+		// with both day of month and day of week either of them matches
+		{"0 7 15 * 6", time.Date(2024, time.March, 30, 7, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tt {
+		assert.Equal(t, tc.expected, MustParse(tc.spec).Next(now), tc.spec)
+	}
+}
+
+func TestDaemonRecoversMissedRun(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	lastRun := time.Now().In(time.UTC).Add(-2 * time.Hour)
+	b, err := json.Marshal(map[string]time.Time{"job": lastRun})
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(statePath, b, 0644))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	runs := 0
+	daemon := &Daemon{
+		StatePath: statePath,
+		Jobs: []Job{{
+			Name:     "job",
+			Schedule: MustParse("0 * * * *"),
+			Run: func() {
+				runs++
+				// the daemon waits for the running jobs on shutdown
+				cancel()
+				time.Sleep(10 * time.Millisecond)
+			},
+		}},
+	}
+
+	require.Nil(t, daemon.Run(ctx))
+	assert.Equal(t, 1, runs)
+
+	b, err = os.ReadFile(statePath)
+	require.Nil(t, err)
+
+	var state map[string]time.Time
+	require.Nil(t, json.Unmarshal(b, &state))
+	assert.True(t, state["job"].After(lastRun))
+}
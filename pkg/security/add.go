@@ -0,0 +1,25 @@
+package security
+
+import (
+	"bytes"
+)
+
+// AppendToCatalog appends the rows (key, name, loader and the optional options) to the catalog,
+// returning the new catalog and the line number of the first appended row.
+func AppendToCatalog(csvBytes []byte, records ...[]string) ([]byte, int) {
+	var out bytes.Buffer
+	out.Write(csvBytes)
+	if len(csvBytes) > 0 && !bytes.HasSuffix(csvBytes, []byte("\n")) {
+		out.WriteString("\n")
+	}
+
+	firstLine := bytes.Count(out.Bytes(), []byte("\n")) + 1
+
+	for _, record := range records {
+		if len(record) == 4 && record[3] == "" {
+			record = record[:3]
+		}
+		out.WriteString(formatRecord(record) + "\n")
+	}
+	return out.Bytes(), firstLine
+}
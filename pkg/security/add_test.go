@@ -0,0 +1,24 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendToCatalog(t *testing.T) {
+	csv := `isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana"`
+
+	newCSV, line := AppendToCatalog([]byte(csv),
+		[]string{"IT0005532723.MOT", "Btp Italia Mz28 Eur", "borsaitaliana", ""},
+		[]string{"IT0005532723.TLX", "Btp Italia Mz28 Eur", "borsaitaliana", "tags=btp"},
+	)
+
+	assert.Equal(t, 3, line)
+	assert.Equal(t, `isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana"
+"IT0005532723.MOT","Btp Italia Mz28 Eur","borsaitaliana"
+"IT0005532723.TLX","Btp Italia Mz28 Eur","borsaitaliana","tags=btp"
+`, string(newCSV))
+}
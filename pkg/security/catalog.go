@@ -0,0 +1,309 @@
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/calendar"
+	"github.com/enrichman/portfolio-performance/pkg/isin"
+	"github.com/enrichman/portfolio-performance/pkg/security/gaps"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+// Security is a QuoteLoader registered in the catalog, together with its metadata.
+type Security struct {
+	quotes.QuoteLoader
+
+	// Loader is the name of the loader used to fetch the quotes.
+	Loader string
+	// Listing is the market (or source) the security is quoted on. The same ISIN can have many listings.
+	Listing string
+	// Default is set on the default listing of the ISIN, published with the ISIN as its ID.
+	Default bool
+	// OutputID is the ID the quotes are published with, if set in the catalog.
+	OutputID string
+	// Aliases are the previous output IDs of the security, still published during their deprecation period.
+	Aliases []Alias
+	// Identifiers are all the known identifiers of the security, i.e. its ISIN, WKN or tickers.
+	Identifiers []Identifier
+	// Tags group the securities, to update them together and publish their combined quotes.
+	Tags []string
+	// Frequency is the expected frequency of the quotes series.
+	Frequency quotes.Frequency
+	// Valuation is the rule used to date the values of a monthly series.
+	Valuation quotes.ValuationRule
+	// Fill is the policy used to fill the gaps in the series.
+	Fill gaps.Policy
+	// Calendar defines the business days the security is expected to be quoted on.
+	Calendar *calendar.Calendar
+	// StaleAfter is the number of business days with no new quotes after which the series is stale.
+	// If zero a default based on the Frequency is used.
+	StaleAfter int
+	// Visibility of the security: the private ones are written in a separate output folder, never published.
+	Visibility Visibility
+	// Status of the security: a retired security is not updated anymore, and its history is kept as it is.
+	Status Status
+	// Maturity is the maturity date of a bond, after which the security is retired.
+	Maturity *time.Time
+	// Delisted is the date the security was delisted, after which it is retired.
+	Delisted *time.Time
+}
+
+// listingPattern matches the listings that can be part of an ID.
+var listingPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// market is implemented by the QuoteLoaders fetching the quotes of a specific market.
+type market interface {
+	Market() string
+}
+
+// ID returns the ID the quotes are published with: the id option if set, the ISIN for the default listing,
+// or "ISIN.LISTING" for the others.
+func (s *Security) ID() string {
+	if s.OutputID != "" {
+		return s.OutputID
+	}
+	if s.Default {
+		return s.ISIN()
+	}
+	return s.ISIN() + "." + s.Listing
+}
+
+// ActiveAliases returns the aliases of the Security still published at the given time.
+func (s *Security) ActiveAliases(t time.Time) []Alias {
+	active := []Alias{}
+	for _, alias := range s.Aliases {
+		if alias.ActiveAt(t) {
+			active = append(active, alias)
+		}
+	}
+	return active
+}
+
+// Visibility of a Security.
+type Visibility string
+
+const (
+	// Public securities are published in the out folder.
+	Public Visibility = "public"
+	// Private securities are written in a separate output folder, that is not published.
+	Private Visibility = "private"
+)
+
+// ParseVisibility parses a Visibility.
+func ParseVisibility(s string) (Visibility, error) {
+	switch visibility := Visibility(s); visibility {
+	case Public, Private:
+		return visibility, nil
+	default:
+		return "", fmt.Errorf("unknown visibility \"%s\" - should be one of public, private", s)
+	}
+}
+
+// WithVisibility returns the securities with the given visibility.
+func WithVisibility(securities []*Security, visibility Visibility) []*Security {
+	filtered := []*Security{}
+	for _, sec := range securities {
+		if sec.Visibility == visibility {
+			filtered = append(filtered, sec)
+		}
+	}
+	return filtered
+}
+
+// Status of a Security.
+type Status string
+
+const (
+	// Active securities are updated at every run.
+	Active Status = "active"
+	// Retired securities are not updated anymore.
+	Retired Status = "retired"
+)
+
+// retireGraceDays are the days after the maturity or delisting date the security is still updated,
+// to collect its last quotes.
+const retireGraceDays = 7
+
+// ParseStatus parses a Status.
+func ParseStatus(s string) (Status, error) {
+	switch status := Status(s); status {
+	case Active, Retired:
+		return status, nil
+	default:
+		return "", fmt.Errorf("unknown status \"%s\" - should be one of active, retired", s)
+	}
+}
+
+// StatusAt returns the Status of the Security at the given time, retiring it after its maturity or delisting date.
+func (s *Security) StatusAt(t time.Time) Status {
+	for _, date := range []*time.Time{s.Maturity, s.Delisted} {
+		if date != nil && t.After(date.AddDate(0, 0, retireGraceDays)) {
+			return Retired
+		}
+	}
+	return s.Status
+}
+
+// ActiveSecurities returns the securities still active at the given time.
+func ActiveSecurities(securities []*Security, t time.Time) []*Security {
+	active := []*Security{}
+	for _, sec := range securities {
+		if sec.StatusAt(t) == Active {
+			active = append(active, sec)
+		}
+	}
+	return active
+}
+
+// newSecurity creates a Security applying the options found in the catalog.
+func newSecurity(loaderName, key string, quoteLoader quotes.QuoteLoader, options map[string]string) (*Security, error) {
+	sec := &Security{
+		QuoteLoader: quoteLoader,
+		Loader:      loaderName,
+		Frequency:   quotes.Daily,
+		Valuation:   quotes.ProviderDate,
+		Fill:        gaps.Leave,
+		Calendar:    calendar.BorsaItaliana,
+		Listing:     loaderName,
+		Status:      Active,
+		Visibility:  Public,
+	}
+
+	if m, ok := quoteLoader.(market); ok {
+		sec.Listing = m.Market()
+	}
+
+	var err error
+	var isinSet bool
+
+	for key, value := range options {
+		if t, found := parseIdentifierType(key); found {
+			identifiers, err := parseIdentifiers(t, value)
+			if err != nil {
+				return nil, err
+			}
+			sec.Identifiers = append(sec.Identifiers, identifiers...)
+			isinSet = isinSet || t == ISINIdentifier
+			continue
+		}
+
+		switch key {
+		case "frequency":
+			sec.Frequency, err = quotes.ParseFrequency(value)
+		case "valuation":
+			sec.Valuation, err = quotes.ParseValuationRule(value)
+		case "fill":
+			sec.Fill, err = gaps.ParsePolicy(value)
+		case "calendar":
+			sec.Calendar, err = calendar.Get(value)
+		case "stale":
+			sec.StaleAfter, err = strconv.Atoi(value)
+			if err != nil || sec.StaleAfter <= 0 {
+				err = fmt.Errorf("wrong stale option \"%s\" - should be a positive number of business days", value)
+			}
+		case "id":
+			sec.OutputID = value
+			if !idPattern.MatchString(value) {
+				err = fmt.Errorf("wrong id \"%s\" - only letters, digits, '.', '-' and '_' are allowed", value)
+			}
+		case "aliases":
+			sec.Aliases, err = parseAliases(value)
+		case "tags":
+			sec.Tags, err = ParseTags(value)
+		case "listing":
+			sec.Listing = value
+		case "default":
+			sec.Default, err = strconv.ParseBool(value)
+			if err != nil {
+				err = fmt.Errorf("wrong default option \"%s\" - should be true or false", value)
+			}
+		case "visibility":
+			sec.Visibility, err = ParseVisibility(value)
+		case "status":
+			sec.Status, err = ParseStatus(value)
+		case "maturity":
+			sec.Maturity, err = parseOptionDate(key, value)
+		case "delisted":
+			sec.Delisted, err = parseOptionDate(key, value)
+		default:
+			err = fmt.Errorf("unknown option \"%s\"", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the ISIN of the loader is a real one only if it has the right format, the pension funds use made up ones
+	if !isinSet && isin.Match(quoteLoader.ISIN()) {
+		sec.Identifiers = append(sec.Identifiers, Identifier{Type: ISINIdentifier, Value: quoteLoader.ISIN()})
+	}
+	sec.Identifiers = append(sec.Identifiers, Identifier{Type: ProviderIdentifier, Value: key})
+	sortIdentifiers(sec.Identifiers)
+
+	if !listingPattern.MatchString(sec.Listing) {
+		return nil, fmt.Errorf("wrong listing \"%s\" - only letters, digits, '-' and '_' are allowed", sec.Listing)
+	}
+
+	if sec.Valuation != quotes.ProviderDate && sec.Frequency != quotes.Monthly {
+		return nil, fmt.Errorf("valuation rule \"%s\" can only be used with monthly series", sec.Valuation)
+	}
+
+	return sec, nil
+}
+
+// ParseTags parses the comma separated tags of the tags option, lowercased and sorted.
+func ParseTags(value string) ([]string, error) {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		if !listingPattern.MatchString(tag) {
+			return nil, fmt.Errorf("wrong tag \"%s\" - only letters, digits, '-' and '_' are allowed", tag)
+		}
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// parseOptionDate parses the date of an option, in the YYYY-MM-DD format.
+func parseOptionDate(key, value string) (*time.Time, error) {
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("wrong %s option \"%s\" - should be YYYY-MM-DD", key, value)
+	}
+	return &date, nil
+}
+
+// parseOptions parses the options column of the catalog, in the "key=value;key=value" format.
+func parseOptions(s string) (map[string]string, error) {
+	options := map[string]string{}
+
+	for _, option := range strings.Split(s, ";") {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(option, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found || key == "" || value == "" {
+			return nil, fmt.Errorf("wrong option format: \"%s\" - should be \"key=value\"", option)
+		}
+		if _, found := options[key]; found {
+			return nil, fmt.Errorf("duplicated option \"%s\"", key)
+		}
+
+		options[key] = value
+	}
+
+	return options, nil
+}
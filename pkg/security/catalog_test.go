@@ -0,0 +1,196 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSecuritiesListings(t *testing.T) {
+	csv := `isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana"
+"IT0005547408.TLX","Btp Valore Gn27 Eur","borsaitaliana"
+"IE00B4L5Y983.ETF","iShares Core MSCI World","borsaitaliana"
+"IE00B4L5Y983.22573329","iShares Core MSCI World","financialtimes","listing=XETRA;default=true"
+"IE00B4L5Y983.ETF","iShares Core MSCI World","borsaitaliana"
+`
+
+	securities, err := LoadSecuritiesFromCSV([]byte(csv))
+	require.Nil(t, err)
+
+	ids := []string{}
+	for _, sec := range securities {
+		ids = append(ids, sec.ID())
+	}
+
+	// the duplicated listing is skipped, and the first listing is the default one if not set
+	assert.Equal(t, []string{
+		"IT0005547408",
+		"IT0005547408.TLX",
+		"IE00B4L5Y983.ETF",
+		"IE00B4L5Y983",
+	}, ids)
+	assert.Equal(t, "XETRA", securities[3].Listing)
+}
+
+func TestLoadSecuritiesWrongListing(t *testing.T) {
+	csv := `isin,name,loader,options
+"IE00B4L5Y983.22573329","iShares Core MSCI World","financialtimes","listing=../XETRA"
+`
+
+	securities, err := LoadSecuritiesFromCSV([]byte(csv))
+	require.Nil(t, err)
+	assert.Empty(t, securities)
+}
+
+func TestLoadSecuritiesIdentifiers(t *testing.T) {
+	csv := `isin,name,loader,options
+"IE00B4L5Y983.ETF","iShares Core MSCI World","borsaitaliana","wkn=A0RPWH;ticker=SWDA.MI,IWDA.AS"
+"FP-Cometa-Reddito.reddito","Fondo Pensione Cometa - Comparto Reddito","cometa","id=CometaReddito;isin=IT0001234563;covip=1234"
+"FP-Cometa-Crescita.crescita","Fondo Pensione Cometa - Comparto Crescita","cometa","id=CometaReddito"
+`
+
+	securities, err := LoadSecuritiesFromCSV([]byte(csv))
+	require.Nil(t, err)
+	require.Len(t, securities, 2)
+
+	assert.Equal(t, "IE00B4L5Y983", securities[0].ID())
+	assert.Equal(t, []Identifier{
+		{Type: ISINIdentifier, Value: "IE00B4L5Y983"},
+		{Type: WKNIdentifier, Value: "A0RPWH"},
+		{Type: TickerIdentifier, Value: "IWDA.AS"},
+		{Type: TickerIdentifier, Value: "SWDA.MI"},
+		{Type: ProviderIdentifier, Value: "IE00B4L5Y983.ETF"},
+	}, securities[0].Identifiers)
+
+	// the output ID is set by the id option, and the securities with an output ID already used are skipped
+	assert.Equal(t, "CometaReddito", securities[1].ID())
+	assert.Equal(t, []Identifier{
+		{Type: ISINIdentifier, Value: "IT0001234563"},
+		{Type: COVIPIdentifier, Value: "1234"},
+		{Type: ProviderIdentifier, Value: "FP-Cometa-Reddito.reddito"},
+	}, securities[1].Identifiers)
+}
+
+func TestLoadCatalogsOverlay(t *testing.T) {
+	public := `isin,name,loader,options
+"IE00B4L5Y983.ETF","iShares Core MSCI World","borsaitaliana"
+`
+	overlay := `isin,name,loader,options
+"IE00B4L5Y983.22573329","iShares Core MSCI World","financialtimes","listing=XETRA;default=true"
+"LU0119620416.1209.A","Global Brands Fund A","morganstanley"
+"IT0005532723.MOT","Btp Italia Mz28 Eur","borsaitaliana","visibility=public"
+"IE00B4L5Y983.ETF","iShares Core MSCI World","borsaitaliana"
+`
+
+	securities, err := LoadCatalogs([]byte(public), []byte(overlay))
+	require.Nil(t, err)
+	require.Len(t, securities, 4)
+
+	// the private listing cannot take the ID of the public one
+	assert.Equal(t, "IE00B4L5Y983", securities[0].ID())
+	assert.Equal(t, Public, securities[0].Visibility)
+	assert.Equal(t, "IE00B4L5Y983.XETRA", securities[1].ID())
+	assert.Equal(t, Private, securities[1].Visibility)
+
+	assert.Equal(t, Private, securities[2].Visibility)
+	assert.Equal(t, Public, securities[3].Visibility)
+	assert.Len(t, WithVisibility(securities, Private), 2)
+}
+
+func TestParseOptions(t *testing.T) {
+	options, err := parseOptions(" frequency=monthly ; valuation = month-end;")
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"frequency": "monthly", "valuation": "month-end"}, options)
+
+	options, err = parseOptions("")
+	require.Nil(t, err)
+	assert.Empty(t, options)
+
+	for _, wrong := range []string{
+		"a=b;;c",
+		"frequency",
+		"=monthly",
+		"frequency=",
+		"frequency=monthly;frequency=daily",
+	} {
+		_, err := parseOptions(wrong)
+		assert.NotNil(t, err, wrong)
+	}
+}
+
+func TestNewSecurityOptions(t *testing.T) {
+	tt := []struct {
+		options string
+		err     string
+	}{
+		{options: "frequency=monthly;valuation=business-month-end;calendar=target2;stale=20"},
+		{options: "a=b;;c", err: "wrong option format"},
+		{options: "color=blue", err: "unknown option \"color\""},
+		{options: "stale=10;stale=20", err: "duplicated option \"stale\""},
+		{options: "frequency=hourly", err: "unknown frequency"},
+		{options: "valuation=month-end", err: "can only be used with monthly series"},
+		{options: "stale=0", err: "wrong stale option"},
+		{options: "maturity=14/03/2032", err: "wrong maturity option"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.options, func(t *testing.T) {
+			sec, err := parseRecord([]string{"IT0005547408.MOT", "Btp Valore Gn27 Eur", "borsaitaliana", tc.options}, Public)
+			if tc.err != "" {
+				require.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, quotes.Monthly, sec.Frequency)
+			assert.Equal(t, quotes.BusinessMonthEnd, sec.Valuation)
+			assert.Equal(t, "target2", sec.Calendar.Name())
+			assert.Equal(t, 20, sec.StaleAfter)
+		})
+	}
+}
+
+func TestStatusAt(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 12, 0, 0, 0, time.UTC)
+	}
+
+	tt := []struct {
+		name     string
+		options  map[string]string
+		at       time.Time
+		expected Status
+	}{
+		{name: "no dates", at: day(6, 1), expected: Active},
+		{name: "before maturity", options: map[string]string{"maturity": "2024-03-14"}, at: day(3, 1), expected: Active},
+		{name: "in the grace days after maturity", options: map[string]string{"maturity": "2024-03-14"}, at: day(3, 20), expected: Active},
+		{name: "7 days after maturity", options: map[string]string{"maturity": "2024-03-14"}, at: day(3, 21), expected: Retired},
+		{name: "in the grace days after delisting", options: map[string]string{"delisted": "2024-03-14"}, at: day(3, 20), expected: Active},
+		{name: "7 days after delisting", options: map[string]string{"delisted": "2024-03-14"}, at: day(3, 21), expected: Retired},
+		{name: "explicitly retired", options: map[string]string{"status": "retired"}, at: day(3, 1), expected: Retired},
+		{name: "explicitly retired before maturity", options: map[string]string{"status": "retired", "maturity": "2032-03-14"}, at: day(3, 1), expected: Retired},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sec := testSecurity(t, "IT0005547408", tc.options)
+			assert.Equal(t, tc.expected, sec.StatusAt(tc.at))
+		})
+	}
+
+	_, err := newSecurity("fake", "IT0005547408", &fakeLoader{isin: "IT0005547408"}, map[string]string{"status": "expired"})
+	assert.NotNil(t, err)
+}
+
+func TestActiveSecurities(t *testing.T) {
+	active := testSecurity(t, "IT0005547408", map[string]string{"maturity": "2027-06-13"})
+	matured := testSecurity(t, "IT0005024580", map[string]string{"maturity": "2020-04-14"})
+	retired := testSecurity(t, "IT0005584062", map[string]string{"status": "retired"})
+
+	at := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []*Security{active}, ActiveSecurities([]*Security{active, matured, retired}, at))
+}
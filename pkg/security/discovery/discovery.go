@@ -0,0 +1,281 @@
+// Package discovery reads the official lists of instruments, i.e. the Italian government bonds outstanding
+// or the ETFs quoted on Borsa Italiana, and proposes the catalog rows of the ones not tracked yet.
+package discovery
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/isin"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+)
+
+// Source is an official list of instruments, downloaded as a CSV or XLSX file.
+type Source string
+
+const (
+	// MEF is the list of the outstanding Italian government bonds of the Ministry of Economy and Finance.
+	MEF Source = "mef"
+	// MOT is the list of the bonds quoted on the MOT market of Borsa Italiana.
+	MOT Source = "mot"
+	// ETFPlus is the list of the ETFs quoted on the ETFplus market of Borsa Italiana.
+	ETFPlus Source = "etfplus"
+)
+
+// tagPattern matches the characters that cannot be part of a tag, i.e. the "€" of "BTP€i".
+var tagPattern = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// Sources are all the supported sources.
+var Sources = []Source{MEF, MOT, ETFPlus}
+
+// ParseSource parses a Source.
+func ParseSource(s string) (Source, error) {
+	if !slices.Contains(Sources, Source(s)) {
+		return "", fmt.Errorf("unknown source \"%s\"", s)
+	}
+	return Source(s), nil
+}
+
+// market returns the Borsa Italiana market the instruments of the Source are quoted on.
+func (s Source) market() string {
+	if s == ETFPlus {
+		return "ETF"
+	}
+	return "MOT"
+}
+
+// tags returns the tags of an instrument of the Source: the government bonds are tagged as govies and with their type.
+func (s Source) tags(inst Instrument) []string {
+	switch s {
+	case MEF:
+		tags := []string{"govies"}
+		kind, _, _ := strings.Cut(strings.ToLower(inst.Type), " ")
+		if kind = tagPattern.ReplaceAllString(kind, ""); kind != "" {
+			tags = append(tags, kind)
+		}
+		return tags
+	case ETFPlus:
+		return []string{"etf"}
+	}
+	return []string{}
+}
+
+// Instrument is a row of an official list.
+type Instrument struct {
+	ISIN   string
+	Name   string
+	Type   string
+	Issuer string
+	// Coupon is the annual coupon rate in percent, if known.
+	Coupon *float64
+	// Maturity is the maturity date of a bond, if known.
+	Maturity *time.Time
+}
+
+// Read reads the instruments of an official list. The rows with no valid ISIN are skipped.
+func Read(data []byte) ([]Instrument, error) {
+	rows, err := readRows(data)
+	if err != nil {
+		return nil, err
+	}
+
+	headerIdx, columns, err := findHeader(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	cell := func(row []string, column string) string {
+		idx, found := columns[column]
+		if !found || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	instruments := []Instrument{}
+	for i, row := range rows[headerIdx+1:] {
+		code := strings.ToUpper(cell(row, "isin"))
+		if !isin.Valid(code) {
+			if code != "" {
+				log.Warnf("row %d: wrong ISIN \"%s\"", headerIdx+i+2, code)
+			}
+			continue
+		}
+
+		inst := Instrument{
+			ISIN:   code,
+			Name:   cell(row, "name"),
+			Type:   cell(row, "type"),
+			Issuer: cell(row, "issuer"),
+		}
+
+		if value := cell(row, "coupon"); value != "" {
+			if inst.Coupon, err = parseCoupon(value); err != nil {
+				log.Warnf("row %d: %s", headerIdx+i+2, err)
+			}
+		}
+		if value := cell(row, "maturity"); value != "" {
+			if inst.Maturity, err = parseDate(value); err != nil {
+				log.Warnf("row %d: %s", headerIdx+i+2, err)
+			}
+		}
+
+		instruments = append(instruments, inst)
+	}
+	return instruments, nil
+}
+
+// name returns the name of the Instrument, or one made of its type, coupon and maturity if the list has no names.
+func (inst Instrument) name() string {
+	if inst.Name != "" {
+		return inst.Name
+	}
+
+	parts := []string{inst.Type}
+	if inst.Coupon != nil {
+		parts = append(parts, strconv.FormatFloat(*inst.Coupon, 'f', -1, 64)+"%")
+	}
+	if inst.Maturity != nil {
+		parts = append(parts, inst.Maturity.Format(time.DateOnly))
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// Rule filters the instruments on a field: the rules on the same field are alternatives, all the fields have to match.
+type Rule struct {
+	Field string
+	Value string
+
+	pattern *regexp.Regexp
+	date    time.Time
+}
+
+// ruleFields are the fields the instruments can be filtered on.
+var ruleFields = []string{"type", "name", "issuer", "country", "maturity-after", "maturity-before"}
+
+// ParseRule parses a Rule in the "field=value" format, i.e. "type=BTP Italia" or "maturity-after=2030-01-01".
+func ParseRule(s string) (Rule, error) {
+	field, value, found := strings.Cut(s, "=")
+	field, value = strings.TrimSpace(field), strings.TrimSpace(value)
+	if !found || value == "" {
+		return Rule{}, fmt.Errorf("wrong rule \"%s\" - should be \"field=value\"", s)
+	}
+
+	rule := Rule{Field: field, Value: value}
+
+	switch field {
+	case "type", "issuer", "country":
+	case "name":
+		pattern, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return Rule{}, fmt.Errorf("wrong name pattern \"%s\": %w", value, err)
+		}
+		rule.pattern = pattern
+	case "maturity-after", "maturity-before":
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return Rule{}, fmt.Errorf("wrong date \"%s\" - should be YYYY-MM-DD", value)
+		}
+		rule.date = date
+	default:
+		return Rule{}, fmt.Errorf("unknown rule field \"%s\" - should be one of %s", field, strings.Join(ruleFields, ", "))
+	}
+	return rule, nil
+}
+
+// match reports if the Instrument matches the Rule. A rule on the maturity doesn't match the instruments without one.
+func (r Rule) match(inst Instrument) bool {
+	switch r.Field {
+	case "type":
+		return strings.EqualFold(inst.Type, r.Value)
+	case "name":
+		return r.pattern.MatchString(inst.name())
+	case "issuer":
+		return strings.Contains(strings.ToLower(inst.Issuer), strings.ToLower(r.Value))
+	case "country":
+		return strings.EqualFold(inst.ISIN[:2], r.Value)
+	case "maturity-after":
+		return inst.Maturity != nil && inst.Maturity.After(r.date)
+	case "maturity-before":
+		return inst.Maturity != nil && inst.Maturity.Before(r.date)
+	}
+	return false
+}
+
+// Match reports if the Instrument matches the rules: at least one rule for every field.
+func Match(inst Instrument, rules []Rule) bool {
+	byField := map[string]bool{}
+	for _, rule := range rules {
+		byField[rule.Field] = byField[rule.Field] || rule.match(inst)
+	}
+	for _, matched := range byField {
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Proposal is a catalog row proposed for an Instrument.
+type Proposal struct {
+	Instrument Instrument
+	Record     []string
+}
+
+// Propose returns the catalog rows of the instruments matching the rules and not tracked yet, i.e. with an ISIN
+// not found in the catalog. The matured bonds are skipped, and the tags are added to the ones of the Source.
+func Propose(source Source, instruments []Instrument, catalog []*security.Security, rules []Rule, tags []string, now time.Time) []Proposal {
+	tracked := map[string]bool{}
+	for _, sec := range catalog {
+		tracked[sec.ISIN()] = true
+		for _, identifier := range sec.Identifiers {
+			if identifier.Type == security.ISINIdentifier {
+				tracked[identifier.Value] = true
+			}
+		}
+	}
+
+	proposals := []Proposal{}
+	for _, inst := range instruments {
+		if tracked[inst.ISIN] || !Match(inst, rules) {
+			continue
+		}
+		if inst.Maturity != nil && inst.Maturity.Before(now) {
+			continue
+		}
+		tracked[inst.ISIN] = true
+
+		options := []string{}
+		if inst.Maturity != nil {
+			options = append(options, "maturity="+inst.Maturity.Format(time.DateOnly))
+		}
+
+		instTags := source.tags(inst)
+		for _, tag := range tags {
+			if !slices.Contains(instTags, tag) {
+				instTags = append(instTags, tag)
+			}
+		}
+		sort.Strings(instTags)
+		if len(instTags) > 0 {
+			options = append(options, "tags="+strings.Join(instTags, ","))
+		}
+
+		proposals = append(proposals, Proposal{
+			Instrument: inst,
+			Record: []string{
+				fmt.Sprintf("%s.%s", inst.ISIN, source.market()),
+				inst.name(),
+				"borsaitaliana",
+				strings.Join(options, ";"),
+			},
+		})
+	}
+	return proposals
+}
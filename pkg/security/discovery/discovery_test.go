@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposeMEF(t *testing.T) {
+	list := `Titoli di Stato in circolazione;;;;
+;;;;
+Tipologia;Codice ISIN;Data emissione;Data scadenza;Cedola
+BTP;IT0005547408;13/06/2023;13/06/2027;3,25
+BTP Italia;IT0005584062;14/03/2024;14/03/2032;1,75
+BTP€i;IT0005409716;15/05/2020;15/05/2030;0,40
+BTP Italia;IT0005024580;14/04/2014;14/04/2020;1,65
+BTP Italia;IT0005565909;01/01/2024;01/01/2030;2,00
+`
+
+	instruments, err := Read([]byte(list))
+	require.Nil(t, err)
+	// the wrong ISIN is skipped
+	require.Len(t, instruments, 4)
+	assert.Equal(t, "BTP Italia", instruments[1].Type)
+	assert.Equal(t, 1.75, *instruments[1].Coupon)
+	assert.Equal(t, time.Date(2032, 3, 14, 0, 0, 0, 0, time.UTC), *instruments[1].Maturity)
+
+	catalog, err := security.LoadSecuritiesFromCSV([]byte(`isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana"
+`))
+	require.Nil(t, err)
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// the tracked and the matured bonds are skipped
+	proposals := Propose(MEF, instruments, catalog, nil, []string{"inflation"}, now)
+	require.Len(t, proposals, 2)
+	assert.Equal(t, []string{"IT0005584062.MOT", "BTP Italia 1.75% 2032-03-14", "borsaitaliana", "maturity=2032-03-14;tags=btp,govies,inflation"}, proposals[0].Record)
+	assert.Equal(t, []string{"IT0005409716.MOT", "BTP€i 0.4% 2030-05-15", "borsaitaliana", "maturity=2030-05-15;tags=btpi,govies,inflation"}, proposals[1].Record)
+
+	rule, err := ParseRule("type=btp italia")
+	require.Nil(t, err)
+	proposals = Propose(MEF, instruments, catalog, []Rule{rule}, nil, now)
+	require.Len(t, proposals, 1)
+	assert.Equal(t, "IT0005584062", proposals[0].Instrument.ISIN)
+}
+
+func TestMatch(t *testing.T) {
+	maturity := time.Date(2032, 3, 14, 0, 0, 0, 0, time.UTC)
+	inst := Instrument{ISIN: "IT0005584062", Type: "BTP Italia", Maturity: &maturity}
+
+	rules := func(s ...string) []Rule {
+		parsed := []Rule{}
+		for _, r := range s {
+			rule, err := ParseRule(r)
+			require.Nil(t, err)
+			parsed = append(parsed, rule)
+		}
+		return parsed
+	}
+
+	assert.True(t, Match(inst, nil))
+	assert.True(t, Match(inst, rules("type=BTP", "type=BTP Italia", "maturity-after=2030-01-01")))
+	assert.False(t, Match(inst, rules("type=BTP Italia", "maturity-before=2030-01-01")))
+	assert.True(t, Match(inst, rules("country=it", "name=italia")))
+
+	_, err := ParseRule("coupon=2")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,116 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// columnNames are the names (lowercase) the columns of an instrument list can have, in Italian or in English.
+var columnNames = map[string][]string{
+	"isin":     {"isin", "codice isin", "cod. isin", "isin code"},
+	"name":     {"descrizione", "denominazione", "nome", "strumento", "name", "description", "instrument"},
+	"type":     {"tipologia", "tipo", "tipo titolo", "type"},
+	"coupon":   {"cedola", "cedola annua", "tasso", "tasso cedolare", "coupon"},
+	"maturity": {"scadenza", "data scadenza", "data di scadenza", "maturity", "maturity date"},
+	"issuer":   {"emittente", "issuer"},
+}
+
+// dateFormats are the formats the dates of an instrument list can have.
+var dateFormats = []string{"02/01/2006", "2/1/2006", "2006-01-02", "02-01-2006", "02.01.2006"}
+
+// readRows reads the rows of a CSV (comma or semicolon separated) or XLSX file.
+func readRows(data []byte) ([][]string, error) {
+	// XLSX files are zip archives
+	if bytes.HasPrefix(data, []byte("PK")) {
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error opening xlsx: %w", err)
+		}
+		defer f.Close()
+
+		rows, err := f.GetRows(f.GetSheetName(0), excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, fmt.Errorf("error reading xlsx: %w", err)
+		}
+		return rows, nil
+	}
+
+	// strip the UTF-8 BOM that Excel adds to exported CSV files
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		csvReader.Comma = ';'
+	}
+
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading csv: %w", err)
+	}
+	return rows, nil
+}
+
+// findHeader returns the index of the header row, the first one with an ISIN column since the official files
+// often have some title rows, and the index of every known column in it.
+func findHeader(rows [][]string) (int, map[string]int, error) {
+	for i, row := range rows {
+		columns := map[string]int{}
+		for j, cell := range row {
+			cell = strings.Join(strings.Fields(strings.ToLower(cell)), " ")
+			for column, names := range columnNames {
+				if _, found := columns[column]; found {
+					continue
+				}
+				for _, name := range names {
+					if cell == name {
+						columns[column] = j
+					}
+				}
+			}
+		}
+		if _, found := columns["isin"]; found {
+			return i, columns, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("no header with an ISIN column found")
+}
+
+// parseDate parses a date in one of the dateFormats, or an Excel serial date.
+func parseDate(value string) (*time.Time, error) {
+	for _, format := range dateFormats {
+		if date, err := time.Parse(format, value); err == nil {
+			return &date, nil
+		}
+	}
+
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		date, err := excelize.ExcelDateToTime(serial, false)
+		if err == nil {
+			date = date.Truncate(24 * time.Hour)
+			return &date, nil
+		}
+	}
+	return nil, fmt.Errorf("wrong date \"%s\"", value)
+}
+
+// parseCoupon parses a coupon rate, with a decimal comma or dot and an optional "%".
+func parseCoupon(value string) (*float64, error) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	value = strings.ReplaceAll(value, ",", ".")
+
+	coupon, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("wrong coupon \"%s\"", value)
+	}
+	return &coupon, nil
+}
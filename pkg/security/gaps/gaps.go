@@ -0,0 +1,185 @@
+package gaps
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/calendar"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+// Policy is the way the missing quotes are filled.
+type Policy string
+
+const (
+	// Leave doesn't fill the missing quotes.
+	Leave Policy = "leave"
+	// ForwardFill repeats the last known close.
+	ForwardFill Policy = "forward"
+	// Interpolate linearly interpolates between the closes around the gap.
+	Interpolate Policy = "interpolate"
+)
+
+// ParsePolicy parses a fill Policy.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case Leave, ForwardFill, Interpolate:
+		return p, nil
+	case "none":
+		// "none" is kept as an alias of the leave policy
+		return Leave, nil
+	}
+	return "", fmt.Errorf("unknown fill policy \"%s\" - should be one of leave, forward, interpolate", s)
+}
+
+// Find returns the dates missing from the quotes since the from date, according to the expected frequency
+// and the business days of the Calendar.
+// Synthetic quotes are ignored, so the gaps they fill are still reported.
+func Find(quotesData []quotes.Quote, frequency quotes.Frequency, rule quotes.ValuationRule, cal *calendar.Calendar, from time.Time) []time.Time {
+	found := map[time.Time]bool{}
+	var first, last time.Time
+
+	for _, q := range quotesData {
+		if q.Synthetic {
+			continue
+		}
+
+		key := periodKey(q.Date, frequency)
+		found[key] = true
+
+		if first.IsZero() || key.Before(first) {
+			first = key
+		}
+		if key.After(last) {
+			last = key
+		}
+	}
+
+	if first.IsZero() || frequency == quotes.Irregular {
+		return nil
+	}
+
+	if from = periodKey(from, frequency); from.After(first) {
+		first = from
+	}
+
+	missing := []time.Time{}
+
+	for period := first; !period.After(last); period = nextPeriod(period, frequency) {
+		if frequency == quotes.Daily && !cal.IsBusinessDay(period) {
+			continue
+		}
+		if found[period] {
+			continue
+		}
+
+		missing = append(missing, expectedDate(period, frequency, rule, cal))
+	}
+
+	return missing
+}
+
+// Fill adds a synthetic quote for each missing date, according to the Policy.
+// The synthetic quotes of the missing dates are computed again, the others (i.e. older than the missing dates
+// looked for) are kept, unless an actual quote was found for their date.
+func Fill(quotesData []quotes.Quote, missing []time.Time, policy Policy) []quotes.Quote {
+	if policy == Leave || policy == "" || len(missing) == 0 {
+		return quotesData
+	}
+
+	missingDates := map[time.Time]bool{}
+	for _, date := range missing {
+		missingDates[date.UTC()] = true
+	}
+
+	actual := []quotes.Quote{}
+	actualDates := map[time.Time]bool{}
+	for _, q := range quotesData {
+		if !q.Synthetic {
+			actual = append(actual, q)
+			actualDates[q.Date.UTC()] = true
+		}
+	}
+
+	sort.Slice(actual, func(i, j int) bool {
+		return actual[i].Date.Before(actual[j].Date)
+	})
+
+	filled := append([]quotes.Quote{}, actual...)
+	for _, q := range quotesData {
+		if q.Synthetic && !missingDates[q.Date.UTC()] && !actualDates[q.Date.UTC()] {
+			filled = append(filled, q)
+		}
+	}
+
+	for _, date := range missing {
+		// index of the first actual quote after the missing date
+		idx := sort.Search(len(actual), func(i int) bool {
+			return actual[i].Date.After(date)
+		})
+		if idx == 0 || idx == len(actual) {
+			continue
+		}
+
+		prev, next := actual[idx-1], actual[idx]
+
+		closeQuote := prev.Close
+		if policy == Interpolate {
+			elapsed := date.Sub(prev.Date).Seconds() / next.Date.Sub(prev.Date).Seconds()
+			closeQuote = prev.Close + float32(elapsed)*(next.Close-prev.Close)
+		}
+
+		filled = append(filled, quotes.Quote{
+			Date:      date,
+			Close:     closeQuote,
+			Synthetic: true,
+		})
+	}
+
+	sort.Slice(filled, func(i, j int) bool {
+		return filled[i].Date.Before(filled[j].Date)
+	})
+
+	return filled
+}
+
+// periodKey returns the start of the period containing the date.
+func periodKey(date time.Time, frequency quotes.Frequency) time.Time {
+	date = date.UTC()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch frequency {
+	case quotes.Weekly:
+		// weeks start on Monday
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case quotes.Monthly:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+func nextPeriod(period time.Time, frequency quotes.Frequency) time.Time {
+	switch frequency {
+	case quotes.Weekly:
+		return period.AddDate(0, 0, 7)
+	case quotes.Monthly:
+		return period.AddDate(0, 1, 0)
+	}
+	return period.AddDate(0, 0, 1)
+}
+
+// expectedDate returns the date a quote is expected for the period.
+func expectedDate(period time.Time, frequency quotes.Frequency, rule quotes.ValuationRule, cal *calendar.Calendar) time.Time {
+	switch frequency {
+	case quotes.Weekly:
+		// last business day of the week
+		return cal.PreviousBusinessDay(period.AddDate(0, 0, 4))
+	case quotes.Monthly:
+		if rule == quotes.ProviderDate {
+			rule = quotes.MonthEnd
+		}
+		return rule.Apply(period, cal)
+	}
+	return period
+}
@@ -0,0 +1,93 @@
+package gaps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/calendar"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestFindDaily(t *testing.T) {
+	// Easter 2024 is on March 31st, so Good Friday and Easter Monday are holidays
+	quotesData := []quotes.Quote{
+		{Date: date(2024, time.March, 26), Close: 10},
+		{Date: date(2024, time.March, 28), Close: 12},
+		{Date: date(2024, time.April, 2), Close: 14},
+	}
+
+	missing := Find(quotesData, quotes.Daily, quotes.ProviderDate, calendar.BorsaItaliana, time.Time{})
+	assert.Equal(t, []time.Time{date(2024, time.March, 27)}, missing)
+
+	missing = Find(quotesData, quotes.Daily, quotes.ProviderDate, calendar.BorsaItaliana, date(2024, time.March, 28))
+	assert.Empty(t, missing)
+}
+
+func TestFindMonthly(t *testing.T) {
+	quotesData := []quotes.Quote{
+		{Date: date(2024, time.January, 31), Close: 10},
+		{Date: date(2024, time.April, 30), Close: 12},
+		{Date: date(2024, time.February, 29), Close: 12, Synthetic: true},
+	}
+
+	// March 29th 2024 is Good Friday
+	missing := Find(quotesData, quotes.Monthly, quotes.BusinessMonthEnd, calendar.BorsaItaliana, time.Time{})
+	assert.Equal(t, []time.Time{date(2024, time.February, 29), date(2024, time.March, 28)}, missing)
+
+	assert.Nil(t, Find(quotesData, quotes.Irregular, quotes.ProviderDate, calendar.BorsaItaliana, time.Time{}))
+}
+
+func TestFill(t *testing.T) {
+	quotesData := []quotes.Quote{
+		{Date: date(2024, time.January, 1), Close: 10},
+		{Date: date(2024, time.January, 5), Close: 14},
+	}
+	missing := []time.Time{date(2024, time.January, 2)}
+
+	assert.Equal(t, quotesData, Fill(quotesData, missing, Leave))
+
+	filled := Fill(quotesData, missing, ForwardFill)
+	require.Len(t, filled, 3)
+	assert.Equal(t, quotes.Quote{Date: missing[0], Close: 10, Synthetic: true}, filled[1])
+
+	filled = Fill(quotesData, missing, Interpolate)
+	require.Len(t, filled, 3)
+	assert.Equal(t, quotes.Quote{Date: missing[0], Close: 11, Synthetic: true}, filled[1])
+}
+
+func TestFillKeepsOldSynthetic(t *testing.T) {
+	// the synthetic quote of 2022 is older than the missing dates looked for, the one of January 3rd is computed again
+	quotesData := []quotes.Quote{
+		{Date: date(2022, time.March, 2), Close: 8, Synthetic: true},
+		{Date: date(2024, time.January, 1), Close: 10},
+		{Date: date(2024, time.January, 3), Close: 10, Synthetic: true},
+		{Date: date(2024, time.January, 5), Close: 14},
+	}
+	missing := []time.Time{date(2024, time.January, 2), date(2024, time.January, 3)}
+
+	filled := Fill(quotesData, missing, Interpolate)
+	assert.Equal(t, []quotes.Quote{
+		{Date: date(2022, time.March, 2), Close: 8, Synthetic: true},
+		{Date: date(2024, time.January, 1), Close: 10},
+		{Date: date(2024, time.January, 2), Close: 11, Synthetic: true},
+		{Date: date(2024, time.January, 3), Close: 12, Synthetic: true},
+		{Date: date(2024, time.January, 5), Close: 14},
+	}, filled)
+}
+
+func TestParsePolicy(t *testing.T) {
+	for value, expected := range map[string]Policy{"leave": Leave, "none": Leave, "forward": ForwardFill, "interpolate": Interpolate} {
+		policy, err := ParsePolicy(value)
+		require.Nil(t, err, value)
+		assert.Equal(t, expected, policy, value)
+	}
+
+	_, err := ParsePolicy("zero")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,163 @@
+package security
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/store"
+)
+
+// Group is the combined quotes of the securities with the same tag, one column per security aligned by date.
+type Group struct {
+	Tag string
+	// IDs are the IDs of the securities of the Group, sorted.
+	IDs []string
+	// Rows are the closes of the securities on every date, in the order of the IDs. A nil close is missing.
+	Rows []GroupRow
+}
+
+// GroupRow is the closes of the securities of a Group on a date.
+type GroupRow struct {
+	Date   time.Time
+	Closes []*float32
+}
+
+// Tags returns all the tags of the securities, sorted.
+func Tags(securities []*Security) []string {
+	tags := []string{}
+	for _, sec := range securities {
+		for _, tag := range sec.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// WithTags returns the securities with at least one of the tags.
+func WithTags(securities []*Security, tags []string) []*Security {
+	tagged := []*Security{}
+	for _, sec := range securities {
+		for _, tag := range tags {
+			if slices.Contains(sec.Tags, tag) {
+				tagged = append(tagged, sec)
+				break
+			}
+		}
+	}
+	return tagged
+}
+
+// LoadGroup loads the combined quotes of the securities with the tag from the Store.
+// The quotes are aligned by day: if a security has many quotes on the same day the last one is used.
+func LoadGroup(st store.Store, securities []*Security, tag string) (Group, error) {
+	group := Group{Tag: tag, IDs: []string{}, Rows: []GroupRow{}}
+
+	members := WithTags(securities, []string{tag})
+	for _, sec := range members {
+		group.IDs = append(group.IDs, sec.ID())
+	}
+	sort.Strings(group.IDs)
+
+	byDay := map[time.Time][]*float32{}
+
+	for i, id := range group.IDs {
+		quotesData, err := st.Load(id)
+		if err != nil {
+			return group, fmt.Errorf("reading series [%s]: %w", id, err)
+		}
+
+		for _, q := range quotesData {
+			day := q.Date.UTC().Truncate(24 * time.Hour)
+			if _, found := byDay[day]; !found {
+				byDay[day] = make([]*float32, len(group.IDs))
+			}
+			closeValue := q.Close
+			byDay[day][i] = &closeValue
+		}
+	}
+
+	for day, closes := range byDay {
+		group.Rows = append(group.Rows, GroupRow{Date: day, Closes: closes})
+	}
+	sort.Slice(group.Rows, func(i, j int) bool {
+		return group.Rows[i].Date.Before(group.Rows[j].Date)
+	})
+
+	return group, nil
+}
+
+// CSV returns the Group in the CSV format, with a date column and a column for every security.
+func (g Group) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	csvWriter := csv.NewWriter(&buf)
+
+	if err := csvWriter.Write(append([]string{"date"}, g.IDs...)); err != nil {
+		return nil, err
+	}
+
+	for _, row := range g.Rows {
+		record := []string{row.Date.Format(time.DateOnly)}
+		for _, closeValue := range row.Closes {
+			if closeValue == nil {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.FormatFloat(float64(*closeValue), 'f', -1, 32))
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	csvWriter.Flush()
+	return buf.Bytes(), csvWriter.Error()
+}
+
+// JSON returns the Group as a list of objects with the date and the close of every security, null if missing.
+func (g Group) JSON() []map[string]any {
+	rows := []map[string]any{}
+	for _, row := range g.Rows {
+		object := map[string]any{"date": row.Date.Format(time.DateOnly)}
+		for i, id := range g.IDs {
+			object[id] = row.Closes[i]
+		}
+		rows = append(rows, object)
+	}
+	return rows
+}
+
+// WriteGroups writes the combined quotes of every tag of the securities, in the groups folder of the dir output folder.
+func WriteGroups(dir string, st store.Store, securities []*Security) error {
+	for _, tag := range Tags(securities) {
+		group, err := LoadGroup(st, securities, tag)
+		if err != nil {
+			return err
+		}
+
+		if err := store.WriteJSON(groupFilename(dir, tag, "json"), group.JSON()); err != nil {
+			return err
+		}
+
+		csvOutput, err := group.CSV()
+		if err != nil {
+			return fmt.Errorf("error formatting group [%s]: %s", tag, err.Error())
+		}
+		if err := store.WriteFile(groupFilename(dir, tag, "csv"), csvOutput); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func groupFilename(dir, tag, ext string) string {
+	return filepath.Join(dir, "groups", fmt.Sprintf("%s.%s", tag, ext))
+}
@@ -0,0 +1,50 @@
+package security
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGroup(t *testing.T) {
+	csv := `isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana","tags=BTP,govies"
+"IT0005532723.MOT","Btp Italia Mz28 Eur","borsaitaliana","tags=btp"
+"AT0000A324S8.MOT","Austria Tf 2,9% Fb33 Eur","borsaitaliana","tags=govies"
+`
+	securities, err := LoadSecuritiesFromCSV([]byte(csv))
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"btp", "govies"}, Tags(securities))
+	assert.Len(t, WithTags(securities, []string{"btp"}), 2)
+
+	st := store.NewJSON(t.TempDir(), t.TempDir())
+	require.Nil(t, st.Replace("IT0005547408", []quotes.Quote{
+		{Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Close: 100.1},
+		{Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Close: 100.2},
+	}))
+	require.Nil(t, st.Replace("IT0005532723", []quotes.Quote{
+		{Date: time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), Close: 99},
+		{Date: time.Date(2024, 1, 3, 17, 30, 0, 0, time.UTC), Close: 99.5},
+	}))
+
+	group, err := LoadGroup(st, securities, "btp")
+	require.Nil(t, err)
+
+	// the quotes are aligned by day, using the last one of the day
+	csvOutput, err := group.CSV()
+	require.Nil(t, err)
+	assert.Equal(t, "date,IT0005532723,IT0005547408\n2024-01-02,,100.1\n2024-01-03,99.5,100.2\n", string(csvOutput))
+
+	jsonOutput, err := json.Marshal(group.JSON())
+	require.Nil(t, err)
+	assert.JSONEq(t, `[
+		{"date": "2024-01-02", "IT0005532723": null, "IT0005547408": 100.1},
+		{"date": "2024-01-03", "IT0005532723": 99.5, "IT0005547408": 100.2}
+	]`, string(jsonOutput))
+}
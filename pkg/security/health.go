@@ -0,0 +1,253 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+	"golang.org/x/exp/maps"
+)
+
+const (
+	healthFilename = "health.json"
+
+	// healthHistoryRuns is the number of runs kept in the history of each series.
+	healthHistoryRuns = 30
+)
+
+// staleThresholds are the default business days after which a series with no new quotes is stale.
+var staleThresholds = map[quotes.Frequency]int{
+	quotes.Daily:     5,
+	quotes.Weekly:    10,
+	quotes.Monthly:   50,
+	quotes.Irregular: 35,
+}
+
+// AlertKind is the kind of an Alert.
+type AlertKind string
+
+const (
+	// FailureAlert is raised when the quotes of a series could not be updated.
+	FailureAlert AlertKind = "failure"
+	// StaleAlert is raised when a series had no new quotes for longer than expected.
+	StaleAlert AlertKind = "stale"
+)
+
+// Alert about the health of a series.
+type Alert struct {
+	ID      string    `json:"id"`
+	Kind    AlertKind `json:"kind"`
+	Message string    `json:"message"`
+	// Since is the time of the first run the alert was raised.
+	Since time.Time `json:"since"`
+	// New is set if the alert was not raised in the previous run.
+	New bool `json:"new"`
+}
+
+// Health of the published series.
+type Health struct {
+	Updated time.Time      `json:"updated"`
+	Series  []SeriesHealth `json:"series"`
+	Alerts  []Alert        `json:"alerts"`
+}
+
+// SeriesHealth records the health of a series across the runs.
+type SeriesHealth struct {
+	ID string `json:"id"`
+	// LastSuccess is the last run the quotes were fetched successfully.
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	// LastNewQuote is the last run that added new quotes.
+	LastNewQuote *time.Time `json:"lastNewQuote,omitempty"`
+	// LastQuoteDate is the date of the most recent quote.
+	LastQuoteDate *time.Time `json:"lastQuoteDate,omitempty"`
+	// FailingSince is the first run of the current streak of failures.
+	FailingSince *time.Time `json:"failingSince,omitempty"`
+	// StaleSince is the first run the series was found stale.
+	StaleSince *time.Time `json:"staleSince,omitempty"`
+	Runs       []Run      `json:"runs"`
+}
+
+// Run is the outcome of a single run for a series.
+type Run struct {
+	Time  time.Time `json:"time"`
+	Rows  int       `json:"rows"`
+	Added int       `json:"added"`
+	Error string    `json:"error,omitempty"`
+}
+
+// LoadHealth loads the Health persisted by the previous runs in the dir output folder.
+func LoadHealth(dir string) (Health, error) {
+	var health Health
+
+	healthFilename := filepath.Join(dir, healthFilename)
+
+	b, err := os.ReadFile(healthFilename)
+	if errors.Is(err, os.ErrNotExist) {
+		return health, nil
+	}
+	if err != nil {
+		return health, fmt.Errorf("error reading file [%s]: %s", healthFilename, err.Error())
+	}
+
+	err = json.Unmarshal(b, &health)
+	if err != nil {
+		return health, fmt.Errorf("error unmarshaling file [%s]: %s", healthFilename, err.Error())
+	}
+
+	return health, nil
+}
+
+// WriteHealth persists the Health in the dir output folder.
+func WriteHealth(dir string, health Health) error {
+	return store.WriteJSON(filepath.Join(dir, healthFilename), health)
+}
+
+// Remove drops a series and its alerts from the Health.
+func (h *Health) Remove(id string) {
+	series := []SeriesHealth{}
+	for _, s := range h.Series {
+		if s.ID != id {
+			series = append(series, s)
+		}
+	}
+	h.Series = series
+
+	alerts := []Alert{}
+	for _, a := range h.Alerts {
+		if a.ID != id {
+			alerts = append(alerts, a)
+		}
+	}
+	h.Alerts = alerts
+}
+
+// Update records the results of the run Report, and raises the alerts for the failing and stale series.
+func (h *Health) Update(report Report, securities []*Security) {
+	seriesMap := map[string]SeriesHealth{}
+	for _, s := range h.Series {
+		seriesMap[s.ID] = s
+	}
+
+	previousAlerts := map[string]Alert{}
+	for _, a := range h.Alerts {
+		previousAlerts[a.ID+"/"+string(a.Kind)] = a
+	}
+
+	securitiesMap := map[string]*Security{}
+	for _, sec := range securities {
+		securitiesMap[sec.ID()] = sec
+	}
+
+	updated := map[string]bool{}
+	for _, result := range report.Results {
+		updated[result.ID] = true
+	}
+
+	runTime := report.End
+	h.Updated = runTime
+
+	// a run can update only some of the series (e.g. the daemon jobs of a loader), so the alerts of the others are kept
+	alerts := []Alert{}
+	for _, a := range h.Alerts {
+		if !updated[a.ID] {
+			a.New = false
+			alerts = append(alerts, a)
+		}
+	}
+	h.Alerts = alerts
+
+	for _, result := range report.Results {
+		series := seriesMap[result.ID]
+		series.ID = result.ID
+
+		series.Runs = append(series.Runs, Run{
+			Time:  runTime,
+			Rows:  result.Loaded,
+			Added: result.Added,
+			Error: result.Error,
+		})
+		if len(series.Runs) > healthHistoryRuns {
+			series.Runs = series.Runs[len(series.Runs)-healthHistoryRuns:]
+		}
+
+		if result.Error == "" {
+			series.LastSuccess = &runTime
+			series.FailingSince = nil
+			if result.Added > 0 {
+				series.LastNewQuote = &runTime
+			}
+			if result.LastDate != nil {
+				series.LastQuoteDate = result.LastDate
+			}
+		} else if series.FailingSince == nil {
+			series.FailingSince = &runTime
+		}
+
+		if series.FailingSince != nil {
+			h.raise(previousAlerts, Alert{
+				ID:      result.ID,
+				Kind:    FailureAlert,
+				Message: result.Error,
+				Since:   *series.FailingSince,
+			})
+		}
+
+		if sec, found := securitiesMap[result.ID]; found && series.LastQuoteDate != nil {
+			threshold := sec.StaleAfter
+			if threshold == 0 {
+				threshold = staleThresholds[sec.Frequency]
+			}
+
+			businessDays := sec.Calendar.BusinessDaysBetween(*series.LastQuoteDate, runTime)
+			if businessDays > threshold {
+				if series.StaleSince == nil {
+					series.StaleSince = &runTime
+				}
+				h.raise(previousAlerts, Alert{
+					ID:      result.ID,
+					Kind:    StaleAlert,
+					Message: fmt.Sprintf("no new quotes since %s (%d business days, threshold %d)", series.LastQuoteDate.Format(time.DateOnly), businessDays, threshold),
+					Since:   *series.StaleSince,
+				})
+			} else {
+				series.StaleSince = nil
+			}
+		}
+
+		seriesMap[result.ID] = series
+	}
+
+	h.Series = maps.Values(seriesMap)
+	sort.Slice(h.Series, func(i, j int) bool {
+		return h.Series[i].ID < h.Series[j].ID
+	})
+	sort.Slice(h.Alerts, func(i, j int) bool {
+		if h.Alerts[i].ID == h.Alerts[j].ID {
+			return h.Alerts[i].Kind < h.Alerts[j].Kind
+		}
+		return h.Alerts[i].ID < h.Alerts[j].ID
+	})
+}
+
+// raise adds the Alert, logging it as structured fields.
+func (h *Health) raise(previousAlerts map[string]Alert, alert Alert) {
+	_, found := previousAlerts[alert.ID+"/"+string(alert.Kind)]
+	alert.New = !found
+
+	log.Warn("alert",
+		"id", alert.ID,
+		"kind", alert.Kind,
+		"message", alert.Message,
+		"since", alert.Since.Format(time.RFC3339),
+		"new", alert.New,
+	)
+
+	h.Alerts = append(h.Alerts, alert)
+}
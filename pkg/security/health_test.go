@@ -0,0 +1,208 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSecurity creates a Security with the options, quoted by a fakeLoader.
+func testSecurity(t *testing.T, isin string, options map[string]string) *Security {
+	sec, err := newSecurity("fake", isin, &fakeLoader{isin: isin}, options)
+	require.Nil(t, err)
+	return sec
+}
+
+// addBusinessDays returns the date n business days after from.
+func addBusinessDays(cal *calendar.Calendar, from time.Time, n int) time.Time {
+	for ; n > 0; n-- {
+		from = cal.NextBusinessDay(from.AddDate(0, 0, 1))
+	}
+	return from
+}
+
+func TestHealthFailure(t *testing.T) {
+	sec := testSecurity(t, "IT0005547408", nil)
+	lastDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	run := func(d int) time.Time { return time.Date(2024, 3, d, 18, 0, 0, 0, time.UTC) }
+
+	tt := []struct {
+		run          time.Time
+		err          string
+		failingSince *time.Time
+		alert        bool
+		alertNew     bool
+	}{
+		{run: run(1)},
+		{run: run(4), err: "unreachable", failingSince: ptr(run(4)), alert: true, alertNew: true},
+		{run: run(5), err: "unreachable", failingSince: ptr(run(4)), alert: true, alertNew: false},
+		{run: run(6)},
+		{run: run(7), err: "unreachable", failingSince: ptr(run(7)), alert: true, alertNew: true},
+	}
+
+	var health Health
+	for _, tc := range tt {
+		result := Result{ID: sec.ID(), Error: tc.err}
+		if tc.err == "" {
+			result.Loaded, result.LastDate = 1, &lastDate
+		}
+		health.Update(Report{End: tc.run, Results: []Result{result}}, []*Security{sec})
+
+		require.Len(t, health.Series, 1)
+		assert.Equal(t, tc.failingSince, health.Series[0].FailingSince, tc.run)
+
+		alerts := alertsOf(health, FailureAlert)
+		if !tc.alert {
+			assert.Empty(t, alerts, tc.run)
+			continue
+		}
+		require.Len(t, alerts, 1, tc.run)
+		assert.Equal(t, *tc.failingSince, alerts[0].Since, tc.run)
+		assert.Equal(t, tc.alertNew, alerts[0].New, tc.run)
+		assert.Equal(t, "unreachable", alerts[0].Message)
+	}
+}
+
+func TestHealthStale(t *testing.T) {
+	lastDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name      string
+		options   map[string]string
+		threshold int
+	}{
+		{name: "daily", options: map[string]string{"frequency": "daily"}, threshold: 5},
+		{name: "weekly", options: map[string]string{"frequency": "weekly"}, threshold: 10},
+		{name: "monthly", options: map[string]string{"frequency": "monthly"}, threshold: 50},
+		{name: "irregular", options: map[string]string{"frequency": "irregular"}, threshold: 35},
+		{name: "override", options: map[string]string{"frequency": "monthly", "stale": "3"}, threshold: 3},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.options["calendar"] = "weekdays"
+			sec := testSecurity(t, "IT0005547408", tc.options)
+
+			var health Health
+			update := func(run time.Time) {
+				result := Result{ID: sec.ID(), Loaded: 1, LastDate: &lastDate}
+				health.Update(Report{End: run, Results: []Result{result}}, []*Security{sec})
+			}
+
+			// on the threshold the series is not stale yet
+			update(addBusinessDays(calendar.Weekdays, lastDate, tc.threshold))
+			assert.Empty(t, alertsOf(health, StaleAlert))
+			assert.Nil(t, health.Series[0].StaleSince)
+
+			staleRun := addBusinessDays(calendar.Weekdays, lastDate, tc.threshold+1)
+			update(staleRun)
+			alerts := alertsOf(health, StaleAlert)
+			require.Len(t, alerts, 1)
+			assert.True(t, alerts[0].New)
+			assert.Equal(t, staleRun, alerts[0].Since)
+
+			// still stale: the alert keeps its start and is not new anymore
+			update(addBusinessDays(calendar.Weekdays, lastDate, tc.threshold+2))
+			alerts = alertsOf(health, StaleAlert)
+			require.Len(t, alerts, 1)
+			assert.False(t, alerts[0].New)
+			assert.Equal(t, staleRun, alerts[0].Since)
+		})
+	}
+}
+
+func TestHealthStaleHolidays(t *testing.T) {
+	// from Friday 20 to Tuesday 31 December 2024 there are 7 weekdays, but only 3 business days
+	// on Borsa Italiana (24, 25, 26 and 31 are holidays)
+	lastDate := time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC)
+	run := time.Date(2024, 12, 31, 18, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		calendar string
+		stale    bool
+	}{
+		{calendar: "borsaitaliana", stale: false},
+		{calendar: "weekdays", stale: true},
+	}
+
+	for _, tc := range tt {
+		sec := testSecurity(t, "IT0005547408", map[string]string{"calendar": tc.calendar})
+
+		var health Health
+		result := Result{ID: sec.ID(), Loaded: 1, LastDate: &lastDate}
+		health.Update(Report{End: run, Results: []Result{result}}, []*Security{sec})
+
+		assert.Equal(t, tc.stale, len(alertsOf(health, StaleAlert)) == 1, tc.calendar)
+	}
+}
+
+func TestHealthRunsTrimmed(t *testing.T) {
+	sec := testSecurity(t, "IT0005547408", nil)
+	start := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+
+	var health Health
+	for i := 0; i < healthHistoryRuns+5; i++ {
+		health.Update(Report{End: start.AddDate(0, 0, i), Results: []Result{{ID: sec.ID(), Error: "unreachable"}}}, []*Security{sec})
+	}
+
+	runs := health.Series[0].Runs
+	require.Len(t, runs, healthHistoryRuns)
+	assert.Equal(t, start.AddDate(0, 0, 5), runs[0].Time)
+	assert.Equal(t, start.AddDate(0, 0, healthHistoryRuns+4), runs[len(runs)-1].Time)
+}
+
+func TestHealthPartialRuns(t *testing.T) {
+	btp := testSecurity(t, "IT0005547408", nil)
+	etf := testSecurity(t, "IE00B4L5Y983", nil)
+	run := func(d int) time.Time { return time.Date(2024, 3, d, 18, 0, 0, 0, time.UTC) }
+	securities := []*Security{btp, etf}
+
+	var health Health
+	failure := func(sec *Security) Report {
+		return Report{Results: []Result{{ID: sec.ID(), Error: "unreachable"}}}
+	}
+
+	// the jobs of two loaders update disjoint series
+	report := failure(btp)
+	report.End = run(4)
+	health.Update(report, securities)
+	report = failure(etf)
+	report.End = run(5)
+	health.Update(report, securities)
+
+	alerts := alertsOf(health, FailureAlert)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, etf.ID(), alerts[0].ID)
+	assert.True(t, alerts[0].New)
+	// the alert of the series not in the run is kept, and not notified again
+	assert.Equal(t, btp.ID(), alerts[1].ID)
+	assert.False(t, alerts[1].New)
+	assert.Equal(t, run(4), alerts[1].Since)
+
+	report = failure(btp)
+	report.End = run(6)
+	health.Update(report, securities)
+
+	alerts = alertsOf(health, FailureAlert)
+	require.Len(t, alerts, 2)
+	assert.False(t, alerts[0].New)
+	assert.False(t, alerts[1].New)
+	assert.Equal(t, run(4), alerts[1].Since)
+}
+
+func alertsOf(health Health, kind AlertKind) []Alert {
+	alerts := []Alert{}
+	for _, a := range health.Alerts {
+		if a.Kind == kind {
+			alerts = append(alerts, a)
+		}
+	}
+	return alerts
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
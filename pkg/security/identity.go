@@ -0,0 +1,131 @@
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/isin"
+)
+
+// IdentifierType is the type of an Identifier.
+type IdentifierType string
+
+const (
+	// ISINIdentifier is the International Securities Identification Number.
+	ISINIdentifier IdentifierType = "isin"
+	// COVIPIdentifier is the code of an Italian pension fund in the COVIP register.
+	COVIPIdentifier IdentifierType = "covip"
+	// WKNIdentifier is the German Wertpapierkennnummer.
+	WKNIdentifier IdentifierType = "wkn"
+	// TickerIdentifier is a ticker symbol, i.e. SWDA.MI.
+	TickerIdentifier IdentifierType = "ticker"
+	// ProviderIdentifier is the code of the security in the catalog, as used by its loader.
+	ProviderIdentifier IdentifierType = "provider"
+)
+
+// identifierTypes are the types of the identifiers that can be set in the catalog options, in their output order.
+var identifierTypes = []IdentifierType{ISINIdentifier, COVIPIdentifier, WKNIdentifier, TickerIdentifier}
+
+// idPattern matches the output IDs, used in the file names and in the URLs.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// Identifier of a Security.
+type Identifier struct {
+	Type  IdentifierType `json:"type"`
+	Value string         `json:"value"`
+}
+
+func (i Identifier) String() string {
+	return string(i.Type) + ":" + i.Value
+}
+
+// Alias is a previous output ID of a Security, still published as a copy of its quotes
+// until the end of its deprecation period.
+type Alias struct {
+	ID string `json:"id"`
+	// Until is the last day the alias is published. If nil the alias is never removed.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+func (a Alias) String() string {
+	if a.Until == nil {
+		return a.ID
+	}
+	return a.ID + ":" + a.Until.Format(time.DateOnly)
+}
+
+// ActiveAt reports if the Alias is still published at the given time.
+func (a Alias) ActiveAt(t time.Time) bool {
+	return a.Until == nil || !t.After(a.Until.AddDate(0, 0, 1))
+}
+
+// parseAliases parses the comma separated aliases of the aliases option, in the "ID" or "ID:YYYY-MM-DD" format.
+func parseAliases(value string) ([]Alias, error) {
+	aliases := []Alias{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		id, until, found := strings.Cut(v, ":")
+		if !idPattern.MatchString(id) {
+			return nil, fmt.Errorf("wrong alias \"%s\" - only letters, digits, '.', '-' and '_' are allowed", id)
+		}
+
+		alias := Alias{ID: id}
+		if found {
+			date, err := time.Parse(time.DateOnly, until)
+			if err != nil {
+				return nil, fmt.Errorf("wrong alias \"%s\" - the deprecation date should be YYYY-MM-DD", v)
+			}
+			alias.Until = &date
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+// parseIdentifierType returns the IdentifierType of a catalog option, if any.
+func parseIdentifierType(key string) (IdentifierType, bool) {
+	for _, t := range identifierTypes {
+		if string(t) == key {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// parseIdentifiers parses the comma separated values of an identifier option.
+func parseIdentifiers(t IdentifierType, value string) ([]Identifier, error) {
+	identifiers := []Identifier{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if t == ISINIdentifier && !isin.Valid(v) {
+			return nil, fmt.Errorf("wrong isin option \"%s\" - should be 12 uppercase letters and digits, with the right check digit", v)
+		}
+		identifiers = append(identifiers, Identifier{Type: t, Value: v})
+	}
+	return identifiers, nil
+}
+
+// sortIdentifiers sorts the identifiers by type, in the order of the identifierTypes, and value.
+func sortIdentifiers(identifiers []Identifier) {
+	order := map[IdentifierType]int{ProviderIdentifier: len(identifierTypes)}
+	for i, t := range identifierTypes {
+		order[t] = i
+	}
+
+	sort.SliceStable(identifiers, func(i, j int) bool {
+		if identifiers[i].Type == identifiers[j].Type {
+			return identifiers[i].Value < identifiers[j].Value
+		}
+		return order[identifiers[i].Type] < order[identifiers[j].Type]
+	})
+}
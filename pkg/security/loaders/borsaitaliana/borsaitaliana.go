@@ -2,9 +2,11 @@ package borsaitaliana
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/enrichman/portfolio-performance/pkg/isin"
 	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
 )
 
@@ -30,6 +32,20 @@ func New(name, isin string) (*QuoteLoader, error) {
 	}, nil
 }
 
+// Markets are the Borsa Italiana markets the quotes can be fetched from.
+var Markets = []string{"MOT", "MTA", "ETF", "TLX"}
+
+// Validate checks the ISIN and the market of the QuoteLoader.
+func (b *QuoteLoader) Validate() error {
+	if !isin.Valid(b.isin) {
+		return fmt.Errorf("wrong ISIN \"%s\" - should be 12 uppercase letters and digits, with the right check digit", b.isin)
+	}
+	if !slices.Contains(Markets, b.market) {
+		return fmt.Errorf("unknown market \"%s\" - should be one of %s", b.market, strings.Join(Markets, ", "))
+	}
+	return nil
+}
+
 // Name returns the QuoteLoader name.
 func (b *QuoteLoader) Name() string {
 	return b.name
@@ -40,6 +56,11 @@ func (b *QuoteLoader) ISIN() string {
 	return b.isin
 }
 
+// Market returns the QuoteLoader market.
+func (b *QuoteLoader) Market() string {
+	return b.market
+}
+
 // LoadQuotes fetches quotes from BorsaItaliana.
 func (b *QuoteLoader) LoadQuotes() ([]quotes.Quote, error) {
 	result, err := fetchData(b.isin, b.market)
@@ -65,3 +65,27 @@ func TestLoadQuotes(t *testing.T) {
 	testDate := time.Unix(int64(testTimestamp/1000), 0).In(time.UTC)
 	assert.Equal(t, testDate, quote.Date)
 }
+
+func TestResolve(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://charts.borsaitaliana.it").
+		Post("/charts/services/ChartWService.asmx/GetPricesWithVolume").
+		BodyString(`IT0005547408\.MOT`).
+		Reply(200).
+		BodyString(testResponse)
+	gock.New("https://charts.borsaitaliana.it").
+		Post("/charts/services/ChartWService.asmx/GetPricesWithVolume").
+		Times(3).
+		Reply(200).
+		BodyString(`{"d": []}`)
+
+	keys, err := (&QuoteLoader{}).Resolve("https://www.borsaitaliana.it/borsa/obbligazioni/mot/btp/scheda/IT0005547408.html")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"IT0005547408.MOT"}, keys)
+
+	_, err = (&QuoteLoader{}).Resolve("https://markets.ft.com/data/etfs/tearsheet/summary?s=IT0005547408:EUR")
+	assert.NotNil(t, err)
+	_, err = (&QuoteLoader{}).Resolve("https://www.notborsaitaliana.it/borsa/obbligazioni/mot/btp/scheda/IT0005547408.html")
+	assert.NotNil(t, err)
+}
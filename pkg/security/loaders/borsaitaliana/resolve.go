@@ -0,0 +1,46 @@
+package borsaitaliana
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/enrichman/portfolio-performance/pkg/isin"
+)
+
+// Resolve returns the keys ("ISIN.MARKET") of the markets the security is quoted on, given its ISIN
+// or the URL of its Borsa Italiana page. Every market is tried, and the ones with no quotes are skipped.
+func (b *QuoteLoader) Resolve(input string) ([]string, error) {
+	if u, err := url.Parse(input); err == nil && u.Host != "" {
+		if host := u.Hostname(); host != "borsaitaliana.it" && !strings.HasSuffix(host, ".borsaitaliana.it") {
+			return nil, fmt.Errorf("not a Borsa Italiana URL: \"%s\"", input)
+		}
+	}
+
+	code := isin.Find(input)
+	if code == "" {
+		return nil, fmt.Errorf("no valid ISIN found in \"%s\"", input)
+	}
+
+	keys := []string{}
+	var lastErr error
+
+	for _, market := range Markets {
+		result, err := fetchData(code, market)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(result.Data) > 0 {
+			keys = append(keys, fmt.Sprintf("%s.%s", code, market))
+		}
+	}
+
+	if len(keys) == 0 {
+		if lastErr != nil {
+			return nil, fmt.Errorf("no quotes found for ISIN %s: %w", code, lastErr)
+		}
+		return nil, fmt.Errorf("no quotes found for ISIN %s on the markets %s", code, strings.Join(Markets, ", "))
+	}
+	return keys, nil
+}
@@ -2,14 +2,19 @@ package financialtimes
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/isin"
 	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
 	"golang.org/x/exp/slices"
 )
 
+// symbolPattern matches the Financial Times IDs of the securities.
+var symbolPattern = regexp.MustCompile(`^[0-9]+$`)
+
 // QuoteLoader struct for FinancialTimes.
 type QuoteLoader struct {
 	name   string
@@ -32,6 +37,17 @@ func New(name, isin string) (*QuoteLoader, error) {
 	}, nil
 }
 
+// Validate checks the ISIN and the symbol of the QuoteLoader.
+func (f *QuoteLoader) Validate() error {
+	if !isin.Valid(f.isin) {
+		return fmt.Errorf("wrong ISIN \"%s\" - should be 12 uppercase letters and digits, with the right check digit", f.isin)
+	}
+	if !symbolPattern.MatchString(f.symbol) {
+		return fmt.Errorf("wrong symbol \"%s\" - should be the numeric ID of the security", f.symbol)
+	}
+	return nil
+}
+
 // Name returns the QuoteLoader name.
 func (f *QuoteLoader) Name() string {
 	return f.name
@@ -69,3 +69,29 @@ func TestLoadQuotes(t *testing.T) {
 	require.Nil(t, err)
 	assert.Equal(t, testDate, quote.Date)
 }
+
+func TestResolve(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://markets.ft.com").
+		Get("/data/search").
+		MatchParam("query", "IE00B4L5Y983").
+		Reply(200).
+		BodyString(`<a href="/data/etfs/tearsheet/summary?s=SWDA:LSE:GBX">iShares Core MSCI World</a>`)
+	gock.New("https://markets.ft.com").
+		Get("/data/etfs/tearsheet/summary").
+		MatchParam("s", "SWDA:LSE:GBX").
+		Reply(200).
+		BodyString(`<div data-mod-config="{&quot;xid&quot;:&quot;22573329&quot;,&quot;symbol&quot;:&quot;SWDA:LSE:GBX&quot;}"></div>`)
+
+	keys, err := (&QuoteLoader{}).Resolve("IE00B4L5Y983")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"IE00B4L5Y983.22573329"}, keys)
+
+	// only the ft.com domain and its subdomains are Financial Times URLs
+	_, err = (&QuoteLoader{}).Resolve("https://www.microsoft.com/data/etfs/tearsheet/summary?s=SWDA:LSE:GBX")
+	assert.NotNil(t, err)
+	// the ISIN is not guessed from the page
+	_, err = (&QuoteLoader{}).Resolve("https://markets.ft.com/data/etfs/tearsheet/summary?s=SWDA:LSE:GBX")
+	assert.ErrorContains(t, err, "by its ISIN")
+}
@@ -0,0 +1,85 @@
+package financialtimes
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/enrichman/portfolio-performance/pkg/isin"
+)
+
+const (
+	financialTimesSearchURLTemplate = "https://markets.ft.com/data/search?query=%s"
+	financialTimesBaseURL           = "https://markets.ft.com"
+)
+
+var (
+	// tearsheetLinkPattern matches the links to the tearsheet pages in the search results.
+	tearsheetLinkPattern = regexp.MustCompile(`href="(/data/[a-z]+/tearsheet/[a-z]+\?s=[^"]+)"`)
+	// xidPattern matches the numeric symbol of the security in the (escaped) config of a tearsheet page.
+	xidPattern = regexp.MustCompile(`xid(?:&quot;|")\s*:\s*(?:&quot;|")([0-9]+)`)
+)
+
+// Resolve returns the key ("ISIN.SYMBOL") of the security given its ISIN or the URL of its Financial Times tearsheet page.
+// With an ISIN the first result of the Financial Times search is used.
+func (f *QuoteLoader) Resolve(input string) ([]string, error) {
+	pageURL := input
+
+	u, err := url.Parse(input)
+	if err != nil || u.Host == "" {
+		if !isin.Valid(input) {
+			return nil, fmt.Errorf("not a valid ISIN or Financial Times URL: \"%s\"", input)
+		}
+
+		results, err := fetchPage(fmt.Sprintf(financialTimesSearchURLTemplate, url.QueryEscape(input)))
+		if err != nil {
+			return nil, err
+		}
+		link := tearsheetLinkPattern.FindStringSubmatch(results)
+		if link == nil {
+			return nil, fmt.Errorf("ISIN %s not found on Financial Times", input)
+		}
+		pageURL = financialTimesBaseURL + strings.ReplaceAll(link[1], "&amp;", "&")
+	} else if host := u.Hostname(); host != "ft.com" && !strings.HasSuffix(host, ".ft.com") {
+		return nil, fmt.Errorf("not a Financial Times URL: \"%s\"", input)
+	}
+
+	// the ISIN is taken only from the input, since the page can list other securities
+	code := isin.Find(input)
+	if code == "" {
+		return nil, fmt.Errorf("no ISIN found in the URL \"%s\" - resolve the security by its ISIN instead", input)
+	}
+
+	page, err := fetchPage(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := xidPattern.FindStringSubmatch(page)
+	if symbol == nil {
+		return nil, fmt.Errorf("no symbol found in the page \"%s\"", pageURL)
+	}
+
+	return []string{fmt.Sprintf("%s.%s", code, symbol[1])}, nil
+}
+
+func fetchPage(url string) (string, error) {
+	res, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("error getting page: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return "", fmt.Errorf("error from request: status_code %d", res.StatusCode)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading body: %w", err)
+	}
+	return string(b), nil
+}
@@ -2,12 +2,17 @@ package fondidoc
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/enrichman/portfolio-performance/pkg/isin"
 	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
 )
 
+// fundIDPattern matches the FondiDoc IDs of the funds, i.e. SAZINTE.
+var fundIDPattern = regexp.MustCompile(`^[A-Z0-9]+$`)
+
 // QuoteLoader struct for FondiDoc.
 type QuoteLoader struct {
 	name   string
@@ -30,6 +35,17 @@ func New(name, isin string) (*QuoteLoader, error) {
 	}, nil
 }
 
+// Validate checks the ISIN and the fund ID of the QuoteLoader.
+func (f *QuoteLoader) Validate() error {
+	if !isin.Valid(f.isin) {
+		return fmt.Errorf("wrong ISIN \"%s\" - should be 12 uppercase letters and digits, with the right check digit", f.isin)
+	}
+	if !fundIDPattern.MatchString(f.fundID) {
+		return fmt.Errorf("wrong fund ID \"%s\" - should be uppercase letters and digits", f.fundID)
+	}
+	return nil
+}
+
 // Name returns the QuoteLoader name.
 func (f *QuoteLoader) Name() string {
 	return f.name
@@ -0,0 +1,22 @@
+package fondidoc
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// fundPagePattern matches the fund ID and the ISIN in the URL of a FondiDoc fund page,
+// i.e. https://www.fondidoc.it/d/Index/SAZINTE/IT0001083424_eurizon-azionario-internazionale-etico
+var fundPagePattern = regexp.MustCompile(`^(https?://)?(www\.)?fondidoc\.it/d/Index/([A-Z0-9]+)/([A-Z]{2}[A-Z0-9]{9}[0-9])`)
+
+// Resolve returns the key ("ISIN.FUNDID") of the security given the URL of its FondiDoc page.
+// The fund ID cannot be found from the ISIN alone.
+func (f *QuoteLoader) Resolve(input string) ([]string, error) {
+	match := fundPagePattern.FindStringSubmatch(input)
+	if match == nil {
+		return nil, fmt.Errorf("not a FondiDoc fund page URL: \"%s\" - should be like \"https://www.fondidoc.it/d/Index/FUNDID/ISIN_name\"", input)
+	}
+
+	fundID, code := match[3], match[4]
+	return []string{fmt.Sprintf("%s.%s", code, fundID)}, nil
+}
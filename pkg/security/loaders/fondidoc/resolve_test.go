@@ -0,0 +1,30 @@
+package fondidoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	for _, input := range []string{
+		"https://www.fondidoc.it/d/Index/SAZINTE/IT0001083424_eurizon-azionario-internazionale-etico",
+		"http://fondidoc.it/d/Index/SAZINTE/IT0001083424",
+		"www.fondidoc.it/d/Index/SAZINTE/IT0001083424_eurizon-azionario-internazionale-etico",
+	} {
+		keys, err := (&QuoteLoader{}).Resolve(input)
+		require.Nil(t, err, input)
+		assert.Equal(t, []string{"IT0001083424.SAZINTE"}, keys, input)
+	}
+
+	for _, input := range []string{
+		"IT0001083424",
+		"https://www.fondidoc.it/d/Index/SAZINTE",
+		"https://www.notfondidoc.it/d/Index/SAZINTE/IT0001083424",
+		"https://example.com/?next=https://www.fondidoc.it/d/Index/SAZINTE/IT0001083424",
+	} {
+		_, err := (&QuoteLoader{}).Resolve(input)
+		assert.NotNil(t, err, input)
+	}
+}
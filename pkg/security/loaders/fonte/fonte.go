@@ -2,6 +2,7 @@ package fonte
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -10,6 +11,9 @@ import (
 	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
 )
 
+// urlNamePattern matches the names of the comparti in the FonTe URLs, i.e. tfr-silente.
+var urlNamePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
 // QuoteLoader struct for FonTe.
 type QuoteLoader struct {
 	name    string
@@ -32,6 +36,14 @@ func New(name, isin string) (*QuoteLoader, error) {
 	}, nil
 }
 
+// Validate checks the URL name of the QuoteLoader.
+func (f *QuoteLoader) Validate() error {
+	if !urlNamePattern.MatchString(f.urlName) {
+		return fmt.Errorf("wrong URL name \"%s\" - should be lowercase letters, digits and '-'", f.urlName)
+	}
+	return nil
+}
+
 // Name returns the QuoteLoader name.
 func (f *QuoteLoader) Name() string {
 	return f.name
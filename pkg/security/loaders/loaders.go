@@ -9,11 +9,19 @@ import (
 	"github.com/enrichman/portfolio-performance/pkg/security/loaders/financialtimes"
 	"github.com/enrichman/portfolio-performance/pkg/security/loaders/fondidoc"
 	"github.com/enrichman/portfolio-performance/pkg/security/loaders/fonte"
+	"github.com/enrichman/portfolio-performance/pkg/security/loaders/manual"
 	"github.com/enrichman/portfolio-performance/pkg/security/loaders/morganstanley"
 	"github.com/enrichman/portfolio-performance/pkg/security/loaders/secondapensione"
+	"github.com/enrichman/portfolio-performance/pkg/security/loaders/spreadsheet"
+	"github.com/enrichman/portfolio-performance/pkg/security/loaders/yahoo"
 	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
 )
 
+// Validator is implemented by the QuoteLoaders that can check the format of their parameters, without fetching the quotes.
+type Validator interface {
+	Validate() error
+}
+
 func New(loader, name, isin string) (quotes.QuoteLoader, error) {
 	switch loader {
 	case "borsaitaliana":
@@ -28,10 +36,44 @@ func New(loader, name, isin string) (quotes.QuoteLoader, error) {
 		return fondidoc.New(name, isin)
 	case "fonte":
 		return fonte.New(name, isin)
+	case "manual":
+		return manual.New(name, isin)
 	case "morganstanley":
 		return morganstanley.New(name, isin)
 	case "secondapensione":
 		return secondapensione.New(name, isin)
+	case "spreadsheet":
+		return spreadsheet.New(name, isin)
+	case "yahoo":
+		return yahoo.New(name, isin)
 	}
 	return nil, fmt.Errorf("quoteLoader [%s] not found", loader)
 }
+
+// Resolver is implemented by the QuoteLoaders that can work out their parameters for a security, given its ISIN
+// or the URL of its page on the provider site. Resolve returns the keys (the first field of the catalog)
+// of the matching securities.
+type Resolver interface {
+	Resolve(input string) ([]string, error)
+}
+
+// Resolvers are the loaders that can work out their parameters, in the order they are tried.
+var Resolvers = []string{"fondidoc", "morganstanley", "borsaitaliana", "financialtimes"}
+
+// resolvers are the Resolvers by loader name. Resolve doesn't use the parameters of the loader, so they are left empty.
+var resolvers = map[string]Resolver{
+	"borsaitaliana":  &borsaitaliana.QuoteLoader{},
+	"financialtimes": &financialtimes.QuoteLoader{},
+	"fondidoc":       &fondidoc.QuoteLoader{},
+	"morganstanley":  &morganstanley.QuoteLoader{},
+}
+
+// Resolve works out the parameters of the loader for a security, given its ISIN or the URL of its page
+// on the provider site, and returns the keys (the first field of the catalog) of the matching securities.
+func Resolve(loader, input string) ([]string, error) {
+	resolver, found := resolvers[loader]
+	if !found {
+		return nil, fmt.Errorf("quoteLoader [%s] cannot resolve its parameters", loader)
+	}
+	return resolver.Resolve(input)
+}
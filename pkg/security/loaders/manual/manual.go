@@ -0,0 +1,30 @@
+package manual
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/loaders/spreadsheet"
+)
+
+const (
+	manualDir = "manual"
+)
+
+// New creates a Manual QuoteLoader, reading the hand-maintained quotes from the "manual/<isin>.csv" file.
+//
+// The file is a CSV with a "date,close" header, dates in the "2006-01-02" format and a dot as decimal separator.
+func New(name, isin string) (*spreadsheet.QuoteLoader, error) {
+	filename := filepath.Join(manualDir, isin+".csv")
+	if _, err := os.Stat(filename); err != nil {
+		return nil, fmt.Errorf("error reading manual quotes [%s]: %w", filename, err)
+	}
+
+	return spreadsheet.NewWithConfig(name, isin, spreadsheet.Config{
+		Path:        filename,
+		Format:      "csv",
+		DateColumn:  "date",
+		CloseColumn: "close",
+	})
+}
@@ -0,0 +1,30 @@
+package manual
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadQuotes(t *testing.T) {
+	// the quotes are read from the manual folder of the working directory
+	t.Chdir("testdata")
+
+	loader, err := New("Polizza Vita - Gestione Separata", "PRIVATE-Policy-Gestione-Separata")
+	require.Nil(t, err)
+	assert.Equal(t, "PRIVATE-Policy-Gestione-Separata", loader.ISIN())
+
+	quotes, err := loader.LoadQuotes()
+	require.Nil(t, err)
+	require.Len(t, quotes, 2)
+
+	assert.Equal(t, time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC), quotes[0].Date)
+	assert.Equal(t, float32(104.21), quotes[0].Close)
+	assert.Equal(t, time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC), quotes[1].Date)
+	assert.Equal(t, float32(107.02), quotes[1].Close)
+
+	_, err = New("Unknown", "PRIVATE-Unknown")
+	require.NotNil(t, err)
+}
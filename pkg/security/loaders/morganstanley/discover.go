@@ -0,0 +1,94 @@
+package morganstanley
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// shareClassInURLPattern matches the share class part of the URL of a fund page, to build the URLs of the other share classes.
+var shareClassInURLPattern = regexp.MustCompile(`\.shareClass\.[A-Za-z0-9]+\.html`)
+
+// ShareClass is a share class of a fund, with the latest NAV in every currency it's published in.
+type ShareClass struct {
+	ID string
+	// BaseCurrency is the currency of the share class.
+	BaseCurrency string
+	NAVs         []NAV
+}
+
+// NAV is the latest NAV of a share class in a currency.
+type NAV struct {
+	Currency string
+	Date     time.Time
+	Value    float32
+}
+
+// Loadable reports if the quotes of the share class can be loaded, since only the NAVs in EUR are fetched.
+func (s ShareClass) Loadable() bool {
+	for _, nav := range s.NAVs {
+		if nav.Currency == "EUR" {
+			return true
+		}
+	}
+	return false
+}
+
+// Discover returns the ID of a fund and all its share classes, given the fund ID or the URL of a fund page.
+func Discover(input string) (string, []ShareClass, error) {
+	fundID := input
+
+	if !fundIDPattern.MatchString(input) {
+		// only the pages of Morgan Stanley are fetched
+		if !isMorganStanleyURL(input) {
+			return "", nil, fmt.Errorf("not a fund ID or a Morgan Stanley URL: \"%s\"", input)
+		}
+
+		page, err := fetchPage(input)
+		if err != nil {
+			return "", nil, err
+		}
+		match := fundIDAttrPattern.FindStringSubmatch(page)
+		if match == nil {
+			return "", nil, fmt.Errorf("no fundId found in the page \"%s\"", input)
+		}
+		fundID = match[1]
+	}
+
+	historicalNav, err := fetchData(fundID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	shareClasses := []ShareClass{}
+	for _, s := range historicalNav.En.ShareClasses {
+		shareClass := ShareClass{ID: s.ID, BaseCurrency: s.Ccy, NAVs: []NAV{}}
+
+		for _, c := range s.Currencies {
+			navs := parseSeries(c.Series)
+			if len(navs) == 0 {
+				continue
+			}
+
+			latest := navs[0]
+			for _, nav := range navs {
+				if nav.Date.After(latest.Date) {
+					latest = nav
+				}
+			}
+			shareClass.NAVs = append(shareClass.NAVs, NAV{Currency: c.ID, Date: latest.Date, Value: latest.Close})
+		}
+
+		shareClasses = append(shareClasses, shareClass)
+	}
+
+	return fundID, shareClasses, nil
+}
+
+// ShareClassURL returns the URL of the page of a share class, given the URL of the page of another share class of the fund.
+func ShareClassURL(pageURL, shareClassID string) (string, error) {
+	if !shareClassInURLPattern.MatchString(pageURL) {
+		return "", fmt.Errorf("not a share class page URL: \"%s\" - should end with \".shareClass.SHARECLASSID.html\"", pageURL)
+	}
+	return shareClassInURLPattern.ReplaceAllString(pageURL, ".shareClass."+shareClassID+".html"), nil
+}
@@ -0,0 +1,60 @@
+package morganstanley
+
+import (
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://www.morganstanley.com").
+		Get("/im/json/imwebdata/data/product/OF/1209/chart/historicalNav.json").
+		Reply(200).
+		BodyString(`{"en": {"shareClasses": [
+			{"id": "A", "ccy": "USD", "currencies": [
+				{"id": "USD", "series": {"category": ["01/02/2024", "01/03/2024"], "data": ["110.5", "111.25"]}},
+				{"id": "EUR", "series": {"category": ["01/02/2024", "01/03/2024"], "data": ["100.5", "101.25"]}}
+			]},
+			{"id": "AX", "ccy": "GBP", "currencies": [
+				{"id": "GBP", "series": {"category": ["01/03/2024", ""], "data": ["90.1", ""]}}
+			]}
+		]}}`)
+
+	fundID, shareClasses, err := Discover("1209")
+	require.Nil(t, err)
+	assert.Equal(t, "1209", fundID)
+	require.Len(t, shareClasses, 2)
+
+	assert.Equal(t, "A", shareClasses[0].ID)
+	assert.Equal(t, "USD", shareClasses[0].BaseCurrency)
+	assert.Equal(t, []NAV{
+		{Currency: "USD", Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Value: 111.25},
+		{Currency: "EUR", Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Value: 101.25},
+	}, shareClasses[0].NAVs)
+	assert.True(t, shareClasses[0].Loadable())
+	assert.False(t, shareClasses[1].Loadable())
+}
+
+func TestDiscoverOtherDomain(t *testing.T) {
+	// the pages of the other domains are not fetched
+	for _, input := range []string{
+		"https://www.notmorganstanley.com/im/it-it/global-brands.shareClass.A.html",
+		"https://morganstanley.com.example.com/im/it-it/global-brands.shareClass.A.html",
+		"http://127.0.0.1/global-brands.shareClass.A.html",
+		"httpx://www.morganstanley.com/im/it-it/global-brands.shareClass.A.html",
+	} {
+		_, _, err := Discover(input)
+		assert.ErrorContains(t, err, "not a fund ID or a Morgan Stanley URL", input)
+	}
+}
+
+func TestShareClassURL(t *testing.T) {
+	url, err := ShareClassURL("https://www.morganstanley.com/im/it-it/global-brands.shareClass.A.html", "AX")
+	require.Nil(t, err)
+	assert.Equal(t, "https://www.morganstanley.com/im/it-it/global-brands.shareClass.AX.html", url)
+}
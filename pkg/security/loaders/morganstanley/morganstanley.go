@@ -2,15 +2,24 @@ package morganstanley
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/isin"
 	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
 	"golang.org/x/exp/slices"
 )
 
+var (
+	// fundIDPattern matches the Morgan Stanley IDs of the funds, i.e. 1209.
+	fundIDPattern = regexp.MustCompile(`^[0-9]+$`)
+	// shareClassIDPattern matches the IDs of the share classes, i.e. A or AHX.
+	shareClassIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+)
+
 // QuoteLoader struct for MorganStanley.
 type QuoteLoader struct {
 	name         string
@@ -35,6 +44,20 @@ func New(name, isin string) (*QuoteLoader, error) {
 	}, nil
 }
 
+// Validate checks the ISIN, the fund ID and the share class ID of the QuoteLoader.
+func (m *QuoteLoader) Validate() error {
+	if !isin.Valid(m.isin) {
+		return fmt.Errorf("wrong ISIN \"%s\" - should be 12 uppercase letters and digits, with the right check digit", m.isin)
+	}
+	if !fundIDPattern.MatchString(m.fundID) {
+		return fmt.Errorf("wrong fund ID \"%s\" - should be a number", m.fundID)
+	}
+	if !shareClassIDPattern.MatchString(m.shareClassID) {
+		return fmt.Errorf("wrong share class ID \"%s\" - should be letters and digits", m.shareClassID)
+	}
+	return nil
+}
+
 // Name returns the QuoteLoader name.
 func (m *QuoteLoader) Name() string {
 	return m.name
@@ -78,17 +101,20 @@ func (m *QuoteLoader) LoadQuotes() ([]quotes.Quote, error) {
 		return nil, nil
 	}
 
-	currency := shareClass.Currencies[eurIdx]
+	return parseSeries(shareClass.Currencies[eurIdx].Series), nil
+}
 
-	if len(currency.Series.Category) != len(currency.Series.Data) {
+// parseSeries parses the NAVs of a currency of a share class, skipping the wrong ones.
+func parseSeries(s series) []quotes.Quote {
+	if len(s.Category) != len(s.Data) {
 		log.Warn("Series Category and Data must be the same length")
-		return nil, nil
+		return nil
 	}
 
 	quotesData := []quotes.Quote{}
 
-	for idx, dateString := range currency.Series.Category {
-		valueString := currency.Series.Data[idx]
+	for idx, dateString := range s.Category {
+		valueString := s.Data[idx]
 
 		if dateString == "" || valueString == "" {
 			continue
@@ -112,5 +138,5 @@ func (m *QuoteLoader) LoadQuotes() ([]quotes.Quote, error) {
 		})
 	}
 
-	return quotesData, nil
+	return quotesData
 }
@@ -0,0 +1,78 @@
+package morganstanley
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/enrichman/portfolio-performance/pkg/isin"
+)
+
+var (
+	// shareClassURLPattern matches the share class ID in the URL of a fund page,
+	// i.e. https://www.morganstanley.com/im/it-it/intermediary-investor/funds-and-performance/morgan-stanley-investment-funds/equity/global-brands.shareClass.A.html
+	shareClassURLPattern = regexp.MustCompile(`^https?://(www\.)?morganstanley\.com/.*\.shareClass\.([A-Za-z0-9]+)\.html`)
+	// fundIDAttrPattern matches the fund ID in the HTML of a fund page, i.e. <div class="bigHeader" fundId="1209"></div>
+	fundIDAttrPattern = regexp.MustCompile(`(?i)fundid="([0-9]+)"`)
+	// isinLabelPattern matches the ISIN of the share class in the HTML of a fund page.
+	isinLabelPattern = regexp.MustCompile(`ISIN[^A-Z0-9]{1,100}([A-Z]{2}[A-Z0-9]{9}[0-9])`)
+)
+
+// Resolve returns the key ("ISIN.FUNDID.SHARECLASSID") of the security given the URL of its Morgan Stanley page.
+// The share class ID is taken from the URL, the fund ID and the ISIN from the HTML of the page.
+func (m *QuoteLoader) Resolve(input string) ([]string, error) {
+	match := shareClassURLPattern.FindStringSubmatch(input)
+	if match == nil {
+		return nil, fmt.Errorf("not a Morgan Stanley fund page URL: \"%s\" - should end with \".shareClass.SHARECLASSID.html\"", input)
+	}
+	shareClassID := match[2]
+
+	page, err := fetchPage(input)
+	if err != nil {
+		return nil, err
+	}
+
+	fundID := fundIDAttrPattern.FindStringSubmatch(page)
+	if fundID == nil {
+		return nil, fmt.Errorf("no fundId found in the page \"%s\"", input)
+	}
+
+	// other ISINs can be found in the page (i.e. of the other share classes), so only the labelled one is used
+	label := isinLabelPattern.FindStringSubmatch(page)
+	if label == nil || !isin.Valid(label[1]) {
+		return nil, fmt.Errorf("no ISIN found in the page \"%s\" - add the security with the key \"ISIN.%s.%s\"", input, fundID[1], shareClassID)
+	}
+
+	return []string{fmt.Sprintf("%s.%s.%s", label[1], fundID[1], shareClassID)}, nil
+}
+
+// isMorganStanleyURL reports if the input is the URL of a page of the morganstanley.com domain, or of its subdomains.
+func isMorganStanleyURL(input string) bool {
+	u, err := url.Parse(input)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	host := u.Hostname()
+	return host == "morganstanley.com" || strings.HasSuffix(host, ".morganstanley.com")
+}
+
+func fetchPage(url string) (string, error) {
+	res, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("error getting page: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return "", fmt.Errorf("error from request: status_code %d", res.StatusCode)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading body: %w", err)
+	}
+	return string(b), nil
+}
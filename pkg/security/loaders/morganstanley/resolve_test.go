@@ -0,0 +1,34 @@
+package morganstanley
+
+import (
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://www.morganstanley.com").
+		Get("/im/it-it/intermediary-investor/funds-and-performance/morgan-stanley-investment-funds/equity/global-brands.shareClass.A.html").
+		Reply(200).
+		BodyString(`<div class="bigHeader" fundId="1209"></div><span>Codice ISIN</span><span>LU0119620416</span>`)
+
+	keys, err := (&QuoteLoader{}).Resolve("https://www.morganstanley.com/im/it-it/intermediary-investor/funds-and-performance/morgan-stanley-investment-funds/equity/global-brands.shareClass.A.html")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"LU0119620416.1209.A"}, keys)
+
+	_, err = (&QuoteLoader{}).Resolve("LU0119620416")
+	assert.NotNil(t, err)
+
+	// without the ISIN label the other ISINs of the page are not guessed
+	gock.New("https://www.morganstanley.com").
+		Get("/im/it-it/intermediary-investor/funds-and-performance/morgan-stanley-investment-funds/equity/global-brands.shareClass.Z.html").
+		Reply(200).
+		BodyString(`<div class="bigHeader" fundId="1209"></div><a href="global-brands.shareClass.A.html">LU0119620416</a>`)
+
+	_, err = (&QuoteLoader{}).Resolve("https://www.morganstanley.com/im/it-it/intermediary-investor/funds-and-performance/morgan-stanley-investment-funds/equity/global-brands.shareClass.Z.html")
+	assert.ErrorContains(t, err, "ISIN.1209.Z")
+}
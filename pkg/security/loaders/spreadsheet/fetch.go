@@ -0,0 +1,35 @@
+package spreadsheet
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+func fetchData(cfg Config) ([]byte, error) {
+	if cfg.Path != "" {
+		b, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		return b, nil
+	}
+
+	res, err := http.Get(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, fmt.Errorf("error from request: status_code %d", res.StatusCode)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+
+	return b, nil
+}
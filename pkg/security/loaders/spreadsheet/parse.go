@@ -0,0 +1,170 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/xuri/excelize/v2"
+)
+
+func readRows(data []byte, cfg Config) ([][]string, error) {
+	switch cfg.format() {
+	case formatCSV:
+		return readCSV(data, cfg)
+	case formatXLSX:
+		return readXLSX(data, cfg)
+	}
+	return nil, fmt.Errorf("unsupported format \"%s\"", cfg.Format)
+}
+
+func readCSV(data []byte, cfg Config) ([][]string, error) {
+	// strip the UTF-8 BOM that Excel adds to exported CSV files
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	if cfg.Delimiter != "" {
+		csvReader.Comma, _ = utf8.DecodeRuneInString(cfg.Delimiter)
+	}
+
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading csv: %w", err)
+	}
+
+	return rows, nil
+}
+
+func readXLSX(data []byte, cfg Config) ([][]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error opening xlsx: %w", err)
+	}
+	defer f.Close()
+
+	sheet := cfg.Sheet
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+
+	// raw values keep dates as serial numbers and decimals with a dot,
+	// regardless of the number format used to display them
+	rows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("error reading sheet \"%s\": %w", sheet, err)
+	}
+
+	return rows, nil
+}
+
+func parseQuotes(rows [][]string, cfg Config) ([]quotes.Quote, error) {
+	if cfg.SkipRows >= len(rows) {
+		return nil, nil
+	}
+	rows = rows[cfg.SkipRows:]
+
+	var header []string
+	if !cfg.NoHeader {
+		header, rows = rows[0], rows[1:]
+	}
+
+	dateIdx, err := columnIndex(header, cfg.DateColumn)
+	if err != nil {
+		return nil, err
+	}
+	closeIdx, err := columnIndex(header, cfg.CloseColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	quotesData := []quotes.Quote{}
+
+	for _, row := range rows {
+		if dateIdx >= len(row) || closeIdx >= len(row) {
+			continue
+		}
+
+		dateString := strings.TrimSpace(row[dateIdx])
+		valueString := strings.TrimSpace(row[closeIdx])
+		if dateString == "" || valueString == "" {
+			continue
+		}
+
+		date, err := parseDate(dateString, cfg)
+		if err != nil {
+			log.Warnf("Error parsing date: %s", err)
+			continue
+		}
+
+		closeQuote, err := parseNumber(valueString, cfg)
+		if err != nil {
+			log.Warnf("Error parsing quote: %s", err)
+			continue
+		}
+
+		quotesData = append(quotesData, quotes.Quote{
+			Date:  date,
+			Close: float32(closeQuote),
+		})
+	}
+
+	return quotesData, nil
+}
+
+// columnIndex resolves a column by its header name or by its 1-based position.
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+
+	position, err := strconv.Atoi(column)
+	if err != nil || position < 1 {
+		return 0, fmt.Errorf("column \"%s\" not found", column)
+	}
+
+	return position - 1, nil
+}
+
+func parseDate(value string, cfg Config) (time.Time, error) {
+	date, err := time.ParseInLocation(cfg.dateFormat(), value, time.UTC)
+	if err == nil {
+		return date, nil
+	}
+
+	// xlsx dates are stored as serial numbers
+	if cfg.format() == formatXLSX {
+		if serial, serialErr := strconv.ParseFloat(value, 64); serialErr == nil {
+			return excelize.ExcelDateToTime(serial, false)
+		}
+	}
+
+	return time.Time{}, err
+}
+
+func parseNumber(value string, cfg Config) (float64, error) {
+	// numeric xlsx cells always use a dot as decimal separator
+	if cfg.format() == formatXLSX {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number, nil
+		}
+	}
+
+	if cfg.Thousands != "" {
+		value = strings.ReplaceAll(value, cfg.Thousands, "")
+	}
+	if cfg.Decimal != "" && cfg.Decimal != "." {
+		value = strings.ReplaceAll(value, cfg.Decimal, ".")
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,160 @@
+package spreadsheet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+const (
+	sourcesDir = "sources"
+
+	formatCSV  = "csv"
+	formatXLSX = "xlsx"
+)
+
+// Config describes where a spreadsheet is found and how its rows are mapped to quotes.
+type Config struct {
+	// URL of the file to download. Mutually exclusive with Path.
+	URL string `json:"url,omitempty"`
+	// Path of a local file. Mutually exclusive with URL.
+	Path string `json:"path,omitempty"`
+	// Format is "csv" or "xlsx". If empty it is guessed from the file extension.
+	Format string `json:"format,omitempty"`
+	// Sheet is the xlsx sheet to read. Defaults to the first one.
+	Sheet string `json:"sheet,omitempty"`
+	// Delimiter is the csv field separator. Defaults to ",".
+	Delimiter string `json:"delimiter,omitempty"`
+	// SkipRows is the number of rows to skip before the header row.
+	SkipRows int `json:"skipRows,omitempty"`
+	// NoHeader must be set if the file has no header row.
+	NoHeader bool `json:"noHeader,omitempty"`
+	// DateColumn and CloseColumn are header names or 1-based column positions.
+	DateColumn  string `json:"dateColumn"`
+	CloseColumn string `json:"closeColumn"`
+	// DateFormat is a Go time layout. Defaults to "2006-01-02".
+	DateFormat string `json:"dateFormat,omitempty"`
+	// Decimal and Thousands are the number separators. Default to "." and none.
+	Decimal   string `json:"decimal,omitempty"`
+	Thousands string `json:"thousands,omitempty"`
+}
+
+// Validate checks that the Config can be used to load quotes.
+func (c Config) Validate() error {
+	if (c.URL == "") == (c.Path == "") {
+		return errors.New("exactly one of url and path must be set")
+	}
+	if c.DateColumn == "" || c.CloseColumn == "" {
+		return errors.New("dateColumn and closeColumn must be set")
+	}
+	if f := c.format(); f != formatCSV && f != formatXLSX {
+		return fmt.Errorf("unsupported format \"%s\" - should be \"csv\" or \"xlsx\"", f)
+	}
+	return nil
+}
+
+func (c Config) format() string {
+	if c.Format != "" {
+		return strings.ToLower(c.Format)
+	}
+
+	name := c.Path
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err == nil {
+			name = path.Base(u.Path)
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".xlsx" || ext == ".xlsm" {
+		return formatXLSX
+	}
+	return formatCSV
+}
+
+func (c Config) dateFormat() string {
+	if c.DateFormat == "" {
+		return "2006-01-02"
+	}
+	return c.DateFormat
+}
+
+// QuoteLoader struct for Spreadsheet.
+type QuoteLoader struct {
+	name   string
+	isin   string
+	config Config
+}
+
+// New creates a Spreadsheet QuoteLoader, reading its Config from the "sources/<source>.json" file.
+func New(name, isin string) (*QuoteLoader, error) {
+	isinSource := strings.Split(isin, ".")
+
+	if len(isinSource) != 2 {
+		return nil, fmt.Errorf("wrong ISIN format for Spreadsheet QuoteLoader: \"%s\" - should be \"ISIN.source\"", isin)
+	}
+
+	filename := filepath.Join(sourcesDir, isinSource[1]+".json")
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading source [%s]: %w", filename, err)
+	}
+
+	var config Config
+	err = json.Unmarshal(b, &config)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling source [%s]: %w", filename, err)
+	}
+
+	return NewWithConfig(name, isinSource[0], config)
+}
+
+// NewWithConfig creates a Spreadsheet QuoteLoader from an explicit Config.
+func NewWithConfig(name, isin string, config Config) (*QuoteLoader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("wrong config for Spreadsheet QuoteLoader: %w", err)
+	}
+
+	return &QuoteLoader{
+		name:   name,
+		isin:   isin,
+		config: config,
+	}, nil
+}
+
+// Name returns the QuoteLoader name.
+func (s *QuoteLoader) Name() string {
+	return s.name
+}
+
+// ISIN returns the QuoteLoader isin.
+func (s *QuoteLoader) ISIN() string {
+	return s.isin
+}
+
+// Config returns the QuoteLoader config.
+func (s *QuoteLoader) Config() Config {
+	return s.config
+}
+
+// LoadQuotes fetches and parses the spreadsheet.
+func (s *QuoteLoader) LoadQuotes() ([]quotes.Quote, error) {
+	data, err := fetchData(s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := readRows(data, s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseQuotes(rows, s.config)
+}
@@ -0,0 +1,77 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	testCSVConfig = Config{
+		URL:         "https://example.com/nav/history.csv",
+		Delimiter:   ";",
+		SkipRows:    1,
+		DateColumn:  "Data",
+		CloseColumn: "NAV",
+		DateFormat:  "02/01/2006",
+		Decimal:     ",",
+		Thousands:   ".",
+	}
+	testCSVResponse = "Storico NAV\nData;NAV\n27/01/2023;1.185,48\n30/01/2023;1.186,48\n"
+)
+
+func TestNewWithConfig(t *testing.T) {
+	loader, err := NewWithConfig("Name", "ISIN", testCSVConfig)
+	require.Nil(t, err)
+	assert.Equal(t, "Name", loader.Name())
+	assert.Equal(t, "ISIN", loader.ISIN())
+	assert.Equal(t, formatCSV, loader.Config().format())
+
+	_, err = NewWithConfig("Name", "ISIN", Config{DateColumn: "1", CloseColumn: "2"})
+	require.NotNil(t, err)
+}
+
+func TestLoadQuotesCSV(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://example.com").
+		Get("/nav/history.csv").
+		Reply(200).
+		BodyString(testCSVResponse)
+
+	loader, err := NewWithConfig("Name", "ISIN", testCSVConfig)
+	require.Nil(t, err)
+
+	quotes, err := loader.LoadQuotes()
+	require.Nil(t, err)
+	require.Len(t, quotes, 2)
+
+	assert.Equal(t, float32(1185.48), quotes[0].Close)
+	assert.Equal(t, time.Date(2023, time.January, 27, 0, 0, 0, 0, time.UTC), quotes[0].Date)
+}
+
+func TestParseQuotesXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	_, err := f.NewSheet("NAV")
+	require.Nil(t, err)
+	require.Nil(t, f.SetSheetRow("NAV", "A1", &[]any{"Date", "Fund", "Price"}))
+	require.Nil(t, f.SetSheetRow("NAV", "A2", &[]any{time.Date(2023, time.January, 27, 0, 0, 0, 0, time.UTC), "X", 185.48}))
+
+	var buf bytes.Buffer
+	require.Nil(t, f.Write(&buf))
+
+	cfg := Config{Path: "history.xlsx", Sheet: "NAV", DateColumn: "1", CloseColumn: "Price"}
+	rows, err := readRows(buf.Bytes(), cfg)
+	require.Nil(t, err)
+
+	quotes, err := parseQuotes(rows, cfg)
+	require.Nil(t, err)
+	require.Len(t, quotes, 1)
+
+	assert.Equal(t, float32(185.48), quotes[0].Close)
+	assert.Equal(t, time.Date(2023, time.January, 27, 0, 0, 0, 0, time.UTC), quotes[0].Date)
+}
@@ -0,0 +1,110 @@
+package yahoo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// yahooURL is a variable so tests can point it to a local server.
+	yahooURL = "https://query1.finance.yahoo.com/v8/finance/chart/"
+)
+
+type responsePayload struct {
+	Chart chart `json:"chart"`
+}
+
+type chart struct {
+	Result []result    `json:"result"`
+	Error  *chartError `json:"error"`
+}
+
+type chartError struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+type result struct {
+	Meta       meta       `json:"meta"`
+	Timestamp  []int64    `json:"timestamp"`
+	Indicators indicators `json:"indicators"`
+	Events     events     `json:"events"`
+}
+
+type meta struct {
+	Currency  string `json:"currency"`
+	Symbol    string `json:"symbol"`
+	GMTOffset int64  `json:"gmtoffset"`
+}
+
+type indicators struct {
+	Quote    []quoteIndicator    `json:"quote"`
+	AdjClose []adjCloseIndicator `json:"adjclose"`
+}
+
+type events struct {
+	Dividends map[string]dividend `json:"dividends"`
+	Splits    map[string]split    `json:"splits"`
+}
+
+type dividend struct {
+	Amount float64 `json:"amount"`
+	Date   int64   `json:"date"`
+}
+
+type split struct {
+	Date        int64   `json:"date"`
+	Numerator   float64 `json:"numerator"`
+	Denominator float64 `json:"denominator"`
+}
+
+type quoteIndicator struct {
+	Close []*float64 `json:"close"`
+}
+
+type adjCloseIndicator struct {
+	AdjClose []*float64 `json:"adjclose"`
+}
+
+func fetchData(symbol, dataRange, interval string) (responsePayload, error) {
+	query := url.Values{}
+	query.Set("range", dataRange)
+	query.Set("interval", interval)
+	query.Set("events", "div,splits")
+
+	req, err := http.NewRequest(http.MethodGet, yahooURL+url.PathEscape(symbol)+"?"+query.Encode(), nil)
+	if err != nil {
+		return responsePayload{}, fmt.Errorf("error creating request: %w", err)
+	}
+	// requests without a browser User-Agent are rate limited
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return responsePayload{}, fmt.Errorf("error getting quotes: %w", err)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return responsePayload{}, fmt.Errorf("error reading body: %w", err)
+	}
+
+	var result responsePayload
+	err = json.Unmarshal(b, &result)
+	if err != nil {
+		if res.StatusCode >= 400 {
+			return responsePayload{}, fmt.Errorf("error from request: status_code %d", res.StatusCode)
+		}
+		return responsePayload{}, fmt.Errorf("error unmarshaling body: %w", err)
+	}
+
+	if result.Chart.Error != nil {
+		return responsePayload{}, fmt.Errorf("error from chart API: %s - %s", result.Chart.Error.Code, result.Chart.Error.Description)
+	}
+
+	return result, nil
+}
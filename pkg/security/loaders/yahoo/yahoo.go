@@ -0,0 +1,200 @@
+package yahoo
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+const (
+	defaultRange    = "max"
+	defaultInterval = "1d"
+)
+
+// QuoteLoader struct for Yahoo.
+type QuoteLoader struct {
+	name     string
+	isin     string
+	symbol   string
+	rng      string
+	interval string
+	adjusted bool
+
+	// response is the chart fetched by LoadQuotes, kept for the following LoadEvents
+	response *responsePayload
+}
+
+// New creates a Yahoo QuoteLoader.
+//
+// The isin has to be in the "ISIN.symbol" format, optionally followed by the "range", "interval"
+// and "adjusted" query parameters, i.e. "IE00B4L5Y983.SWDA.MI?range=5y&adjusted=true".
+func New(name, isin string) (*QuoteLoader, error) {
+	isinSymbol, rawQuery, _ := strings.Cut(isin, "?")
+
+	isin, symbol, found := strings.Cut(isinSymbol, ".")
+	if !found || isin == "" || symbol == "" {
+		return nil, fmt.Errorf("wrong ISIN format for Yahoo QuoteLoader: \"%s\" - should be \"ISIN.symbol\"", isinSymbol)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("wrong options for Yahoo QuoteLoader: \"%s\": %w", rawQuery, err)
+	}
+
+	loader := &QuoteLoader{
+		name:     name,
+		isin:     isin,
+		symbol:   symbol,
+		rng:      defaultRange,
+		interval: defaultInterval,
+	}
+
+	if rng := query.Get("range"); rng != "" {
+		loader.rng = rng
+	}
+	if interval := query.Get("interval"); interval != "" {
+		loader.interval = interval
+	}
+	if adjusted := query.Get("adjusted"); adjusted != "" {
+		loader.adjusted, err = strconv.ParseBool(adjusted)
+		if err != nil {
+			return nil, fmt.Errorf("wrong adjusted option for Yahoo QuoteLoader: \"%s\"", adjusted)
+		}
+	}
+
+	return loader, nil
+}
+
+// Name returns the QuoteLoader name.
+func (y *QuoteLoader) Name() string {
+	return y.name
+}
+
+// ISIN returns the QuoteLoader isin.
+func (y *QuoteLoader) ISIN() string {
+	return y.isin
+}
+
+// Symbol returns the QuoteLoader symbol.
+func (y *QuoteLoader) Symbol() string {
+	return y.symbol
+}
+
+// LoadQuotes fetches quotes from Yahoo.
+func (y *QuoteLoader) LoadQuotes() ([]quotes.Quote, error) {
+	response, err := fetchData(y.symbol, y.rng, y.interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// the chart already includes the events, so they are loaded without fetching it again
+	if !y.adjusted {
+		y.response = &response
+	}
+
+	if len(response.Chart.Result) == 0 {
+		return nil, nil
+	}
+
+	result := response.Chart.Result[0]
+
+	var values []*float64
+	if y.adjusted {
+		if len(result.Indicators.AdjClose) == 0 {
+			return nil, nil
+		}
+		values = result.Indicators.AdjClose[0].AdjClose
+	} else {
+		if len(result.Indicators.Quote) == 0 {
+			return nil, nil
+		}
+		values = result.Indicators.Quote[0].Close
+	}
+
+	if len(result.Timestamp) != len(values) {
+		log.Warn("Timestamps and Values must be the same length")
+		return nil, nil
+	}
+
+	quotesData := []quotes.Quote{}
+
+	for idx, timestamp := range result.Timestamp {
+		value := values[idx]
+		if value == nil {
+			continue
+		}
+
+		quotesData = append(quotesData, quotes.Quote{
+			Date:  tradingDate(timestamp, result.Meta.GMTOffset),
+			Close: float32(*value),
+		})
+	}
+
+	return quotesData, nil
+}
+
+// LoadEvents returns the dividends and splits of the chart fetched by the last LoadQuotes,
+// fetching it only if the quotes were not loaded before.
+// No events are returned when the adjusted close is used, since the series is already adjusted.
+func (y *QuoteLoader) LoadEvents() ([]quotes.Event, error) {
+	if y.adjusted {
+		return nil, nil
+	}
+
+	var response responsePayload
+	if y.response != nil {
+		// the cached chart is used once, so a later run fetches fresh data
+		response, y.response = *y.response, nil
+	} else {
+		var err error
+		response, err = fetchData(y.symbol, y.rng, y.interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(response.Chart.Result) == 0 {
+		return nil, nil
+	}
+
+	result := response.Chart.Result[0]
+
+	eventsData := []quotes.Event{}
+
+	for _, d := range result.Events.Dividends {
+		eventsData = append(eventsData, quotes.Event{
+			Date:   tradingDate(d.Date, result.Meta.GMTOffset),
+			Type:   quotes.Dividend,
+			Amount: d.Amount,
+		})
+	}
+
+	for _, s := range result.Events.Splits {
+		if s.Denominator == 0 {
+			continue
+		}
+
+		eventsData = append(eventsData, quotes.Event{
+			Date:  tradingDate(s.Date, result.Meta.GMTOffset),
+			Type:  quotes.Split,
+			Ratio: s.Numerator / s.Denominator,
+		})
+	}
+
+	sort.Slice(eventsData, func(i, j int) bool {
+		return eventsData[i].Date.Before(eventsData[j].Date)
+	})
+
+	return eventsData, nil
+}
+
+// tradingDate returns the day of the timestamp in the exchange timezone.
+func tradingDate(timestamp, gmtOffset int64) time.Time {
+	return time.Unix(timestamp+gmtOffset, 0).UTC().Truncate(24 * time.Hour)
+}
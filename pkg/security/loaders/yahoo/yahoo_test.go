@@ -0,0 +1,133 @@
+package yahoo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	loader, err := New("Name", "ISIN.SYMBOL.MI")
+	require.Nil(t, err)
+	assert.Equal(t, "Name", loader.name)
+	assert.Equal(t, "Name", loader.Name())
+	assert.Equal(t, "ISIN", loader.isin)
+	assert.Equal(t, "ISIN", loader.ISIN())
+	assert.Equal(t, "SYMBOL.MI", loader.symbol)
+	assert.Equal(t, defaultRange, loader.rng)
+	assert.Equal(t, defaultInterval, loader.interval)
+	assert.False(t, loader.adjusted)
+
+	loader, err = New("Name", "ISIN.SYMBOL?range=5y&interval=1wk&adjusted=true")
+	require.Nil(t, err)
+	assert.Equal(t, "SYMBOL", loader.symbol)
+	assert.Equal(t, "5y", loader.rng)
+	assert.Equal(t, "1wk", loader.interval)
+	assert.True(t, loader.adjusted)
+
+	_, err = New("Name", "ISIN")
+	require.NotNil(t, err)
+}
+
+// setServer starts a local stand-in of the chart API serving the recorded responses in testdata.
+// It returns the counter of the requests served.
+func setServer(t *testing.T) *atomic.Int32 {
+	requests := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		b, err := os.ReadFile(filepath.Join("testdata", path.Base(r.URL.Path)+".json"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(server.Close)
+
+	oldURL := yahooURL
+	yahooURL = server.URL + "/v8/finance/chart/"
+	t.Cleanup(func() { yahooURL = oldURL })
+
+	return requests
+}
+
+func TestFetchData(t *testing.T) {
+	setServer(t)
+
+	response, err := fetchData("SWDA.MI", "max", "1d")
+	require.Nil(t, err)
+	require.Len(t, response.Chart.Result, 1)
+
+	result := response.Chart.Result[0]
+	assert.Equal(t, "EUR", result.Meta.Currency)
+	require.Len(t, result.Timestamp, 3)
+	require.Len(t, result.Indicators.Quote, 1)
+	require.Len(t, result.Indicators.Quote[0].Close, 3)
+	assert.Nil(t, result.Indicators.Quote[0].Close[2])
+
+	_, err = fetchData("UNKNOWN", "max", "1d")
+	require.NotNil(t, err)
+}
+
+func TestLoadQuotes(t *testing.T) {
+	setServer(t)
+
+	loader, err := New("Name", "ISIN.SWDA.MI")
+	require.Nil(t, err)
+
+	quotes, err := loader.LoadQuotes()
+	require.Nil(t, err)
+	require.Len(t, quotes, 2)
+
+	quote := quotes[0]
+	assert.Equal(t, float32(75.48), quote.Close)
+	assert.Equal(t, time.Date(2023, time.January, 27, 0, 0, 0, 0, time.UTC), quote.Date)
+}
+
+func TestLoadEvents(t *testing.T) {
+	setServer(t)
+
+	loader, err := New("Name", "ISIN.SWDA.MI")
+	require.Nil(t, err)
+
+	events, err := loader.LoadEvents()
+	require.Nil(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, quotes.Dividend, events[0].Type)
+	assert.Equal(t, 0.25, events[0].Amount)
+	assert.Equal(t, time.Date(2023, time.January, 30, 0, 0, 0, 0, time.UTC), events[0].Date)
+
+	assert.Equal(t, quotes.Split, events[1].Type)
+	assert.Equal(t, 2.0, events[1].Ratio)
+}
+
+func TestLoadEventsFromQuotesResponse(t *testing.T) {
+	requests := setServer(t)
+
+	loader, err := New("Name", "ISIN.SWDA.MI")
+	require.Nil(t, err)
+
+	_, err = loader.LoadQuotes()
+	require.Nil(t, err)
+
+	events, err := loader.LoadEvents()
+	require.Nil(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// the cached chart is not reused by the next run
+	_, err = loader.LoadEvents()
+	require.Nil(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+}
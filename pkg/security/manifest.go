@@ -0,0 +1,180 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+)
+
+const (
+	manifestFilename = "manifest.json"
+)
+
+// ManifestEntry describes a published quotes series.
+type ManifestEntry struct {
+	ID          string               `json:"id"`
+	ISIN        string               `json:"isin"`
+	Listing     string               `json:"listing"`
+	Default     bool                 `json:"default"`
+	Identifiers []Identifier         `json:"identifiers"`
+	Aliases     []Alias              `json:"aliases,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Name        string               `json:"name"`
+	Loader      string               `json:"loader"`
+	Frequency   quotes.Frequency     `json:"frequency"`
+	Valuation   quotes.ValuationRule `json:"valuation,omitempty"`
+	Calendar    string               `json:"calendar"`
+	Status      Status               `json:"status"`
+	Maturity    *time.Time           `json:"maturity,omitempty"`
+	Delisted    *time.Time           `json:"delisted,omitempty"`
+	Path        string               `json:"path"`
+	From        *time.Time           `json:"from,omitempty"`
+	To          *time.Time           `json:"to,omitempty"`
+	Quotes      int                  `json:"quotes"`
+}
+
+// WriteManifest writes the manifest of the series, with their metadata, in the dir output folder
+func WriteManifest(dir string, st store.Store, securities []*Security) error {
+	manifest := []ManifestEntry{}
+
+	for _, sec := range securities {
+		entry := ManifestEntry{
+			ID:          sec.ID(),
+			ISIN:        sec.ISIN(),
+			Listing:     sec.Listing,
+			Default:     sec.Default,
+			Identifiers: sec.Identifiers,
+			Aliases:     sec.ActiveAliases(time.Now()),
+			Tags:        sec.Tags,
+			Name:        sec.Name(),
+			Loader:      sec.Loader,
+			Frequency:   sec.Frequency,
+			Calendar:    sec.Calendar.Name(),
+			Status:      sec.StatusAt(time.Now()),
+			Maturity:    sec.Maturity,
+			Delisted:    sec.Delisted,
+			Path:        fmt.Sprintf("json/%s.json", sec.ID()),
+		}
+		if sec.Frequency == quotes.Monthly {
+			entry.Valuation = sec.Valuation
+		}
+
+		publishedQuotes, err := st.Load(entry.ID)
+		if err != nil {
+			return err
+		}
+		if len(publishedQuotes) > 0 {
+			entry.From = &publishedQuotes[0].Date
+			entry.To = &publishedQuotes[len(publishedQuotes)-1].Date
+			entry.Quotes = len(publishedQuotes)
+		}
+
+		manifest = append(manifest, entry)
+	}
+
+	sort.Slice(manifest, func(i, j int) bool {
+		return manifest[i].ID < manifest[j].ID
+	})
+
+	return store.WriteJSON(filepath.Join(dir, manifestFilename), manifest)
+}
+
+// ReadManifest reads the manifest written in the dir output folder. A missing manifest has no entries.
+func ReadManifest(dir string) ([]ManifestEntry, error) {
+	filename := filepath.Join(dir, manifestFilename)
+
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading file [%s]: %s", filename, err.Error())
+	}
+
+	var manifest []ManifestEntry
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("error unmarshaling file [%s]: %s", filename, err.Error())
+	}
+	return manifest, nil
+}
+
+// CheckDefaultListings returns an error if a series published in the dir output folder now belongs to another listing,
+// i.e. when the default option is moved to a new listing of an ISIN. The prices of the new listing would be merged
+// into the published history of the old one, changing the content of its URL.
+func CheckDefaultListings(dir string, st store.Store, securities []*Security) error {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	byID := map[string]*Security{}
+	for _, sec := range securities {
+		byID[sec.ID()] = sec
+	}
+
+	for _, entry := range manifest {
+		sec, found := byID[entry.ID]
+		if !found || sec.Listing == entry.Listing {
+			continue
+		}
+
+		publishedQuotes, err := st.Load(entry.ID)
+		if err != nil {
+			return err
+		}
+		if len(publishedQuotes) == 0 {
+			continue
+		}
+
+		return fmt.Errorf("series '%s' is published for listing '%s': keep the default option on it, "+
+			"moving it to listing '%s' would merge its prices into the published ones", entry.ID, entry.Listing, sec.Listing)
+	}
+	return nil
+}
+
+// DeleteSeries removes a series from the Store, together with its events and adjusted quotes in the dir output folder.
+func DeleteSeries(dir string, st store.Store, id string) error {
+	if err := st.Delete(id); err != nil {
+		return err
+	}
+
+	for _, filename := range []string{eventsFilename(dir, id), adjustedFilename(dir, id)} {
+		err := os.Remove(filename)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error removing file [%s]: %s", filename, err.Error())
+		}
+	}
+	return nil
+}
+
+// Orphans returns the IDs of the series in the Store not found in the catalog, sorted.
+// The aliases still published are not orphans, the expired ones are.
+func Orphans(st store.Store, securities []*Security) ([]string, error) {
+	catalog := map[string]bool{}
+	for _, sec := range securities {
+		catalog[sec.ID()] = true
+		for _, alias := range sec.ActiveAliases(time.Now()) {
+			catalog[alias.ID] = true
+		}
+	}
+
+	ids, err := st.IDs()
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []string{}
+	for _, id := range ids {
+		if !catalog[id] {
+			orphans = append(orphans, id)
+		}
+	}
+	return orphans, nil
+}
@@ -0,0 +1,135 @@
+package security
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteManifest(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, time.March, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	csv := `isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana"
+"IE00B4L5Y983.ETF","iShares Core MSCI World","borsaitaliana","frequency=monthly;valuation=month-end"
+`
+	securities, err := LoadSecuritiesFromCSV([]byte(csv))
+	require.Nil(t, err)
+
+	st := store.NewJSON(t.TempDir(), t.TempDir())
+	require.Nil(t, st.Put("IT0005547408", []quotes.Quote{
+		{Date: day(4), Close: 100},
+		{Date: day(5), Close: 101},
+		{Date: day(6), Close: 102},
+	}))
+
+	dir := t.TempDir()
+	require.Nil(t, WriteManifest(dir, st, securities))
+
+	b, err := os.ReadFile(filepath.Join(dir, manifestFilename))
+	require.Nil(t, err)
+	var manifest []ManifestEntry
+	require.Nil(t, json.Unmarshal(b, &manifest))
+
+	// the entries are sorted by ID
+	require.Len(t, manifest, 2)
+
+	assert.Equal(t, "IE00B4L5Y983", manifest[0].ID)
+	assert.Nil(t, manifest[0].From)
+	assert.Nil(t, manifest[0].To)
+	assert.Zero(t, manifest[0].Quotes)
+	assert.Equal(t, quotes.MonthEnd, manifest[0].Valuation)
+
+	assert.Equal(t, "IT0005547408", manifest[1].ID)
+	assert.Equal(t, "json/IT0005547408.json", manifest[1].Path)
+	require.NotNil(t, manifest[1].From)
+	require.NotNil(t, manifest[1].To)
+	assert.Equal(t, day(4), *manifest[1].From)
+	assert.Equal(t, day(6), *manifest[1].To)
+	assert.Equal(t, 3, manifest[1].Quotes)
+	// the valuation rule is published only for the monthly series
+	assert.Empty(t, manifest[1].Valuation)
+}
+
+func TestOrphans(t *testing.T) {
+	csv := `isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana","aliases=BTP-GN27,BTP-VALORE:2999-12-31,IT0005547408.MOT:2020-01-01"
+`
+	securities, err := LoadSecuritiesFromCSV([]byte(csv))
+	require.Nil(t, err)
+
+	st := store.NewJSON(t.TempDir(), t.TempDir())
+	for _, id := range []string{"IT0005547408", "BTP-GN27", "BTP-VALORE", "IT0005547408.MOT", "IE00B4L5Y983"} {
+		require.Nil(t, st.Put(id, []quotes.Quote{{Date: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), Close: 100}}))
+	}
+
+	// the active aliases are published with the security, the expired one is orphaned
+	orphans, err := Orphans(st, securities)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"IE00B4L5Y983", "IT0005547408.MOT"}, orphans)
+}
+
+func TestDeleteSeries(t *testing.T) {
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	dir := t.TempDir()
+	st := store.NewJSON(t.TempDir(), t.TempDir())
+	require.Nil(t, st.Put("IT0005547408", []quotes.Quote{{Date: day, Close: 100}}))
+	require.Nil(t, store.WriteJSON(eventsFilename(dir, "IT0005547408"), []quotes.Event{{Date: day, Type: quotes.Dividend, Amount: 1}}))
+	require.Nil(t, store.WriteJSON(adjustedFilename(dir, "IT0005547408"), []quotes.Quote{{Date: day, Close: 99}}))
+
+	require.Nil(t, DeleteSeries(dir, st, "IT0005547408"))
+
+	ids, err := st.IDs()
+	require.Nil(t, err)
+	assert.Empty(t, ids)
+	for _, filename := range []string{eventsFilename(dir, "IT0005547408"), adjustedFilename(dir, "IT0005547408")} {
+		_, err := os.Stat(filename)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	}
+
+	// a series without events and adjusted quotes is deleted too
+	require.Nil(t, st.Put("IE00B4L5Y983", []quotes.Quote{{Date: day, Close: 100}}))
+	require.Nil(t, DeleteSeries(dir, st, "IE00B4L5Y983"))
+}
+
+func TestCheckDefaultListings(t *testing.T) {
+	published := `isin,name,loader,options
+"IE00B4L5Y983.ETF","iShares Core MSCI World","borsaitaliana"
+`
+	securities, err := LoadSecuritiesFromCSV([]byte(published))
+	require.Nil(t, err)
+
+	dir := t.TempDir()
+	st := store.NewJSON(t.TempDir(), t.TempDir())
+	require.Nil(t, st.Put("IE00B4L5Y983", []quotes.Quote{{Date: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), Close: 100}}))
+	require.Nil(t, WriteManifest(dir, st, securities))
+	require.Nil(t, CheckDefaultListings(dir, st, securities))
+
+	// a new listing added after the published one is fine
+	securities, err = LoadSecuritiesFromCSV([]byte(published + `"IE00B4L5Y983.22573329","iShares Core MSCI World","financialtimes","listing=XETRA"
+`))
+	require.Nil(t, err)
+	require.Nil(t, CheckDefaultListings(dir, st, securities))
+
+	// moving the default to it would merge the XETRA prices into the published series
+	securities, err = LoadSecuritiesFromCSV([]byte(published + `"IE00B4L5Y983.22573329","iShares Core MSCI World","financialtimes","listing=XETRA;default=true"
+`))
+	require.Nil(t, err)
+	err = CheckDefaultListings(dir, st, securities)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "published for listing 'ETF'")
+
+	// a series never published can change listing
+	require.Nil(t, st.Delete("IE00B4L5Y983"))
+	require.Nil(t, CheckDefaultListings(dir, st, securities))
+}
@@ -0,0 +1,235 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+)
+
+// Migrate moves the series oldID to newID, together with its history and its events in the dir output folder.
+// If newID already has quotes the two histories are merged, and on the same date the last observed version wins.
+// It returns the number of quotes of the migrated series.
+func Migrate(dir string, st store.Store, oldID, newID string) (int, error) {
+	if oldID == newID {
+		return 0, fmt.Errorf("the new ID is the same of the old one")
+	}
+
+	oldHistory, err := st.History(oldID)
+	if err != nil {
+		return 0, fmt.Errorf("reading series [%s]: %w", oldID, err)
+	}
+	if len(oldHistory) == 0 {
+		return 0, fmt.Errorf("series [%s] not found", oldID)
+	}
+
+	newHistory, err := st.History(newID)
+	if err != nil {
+		return 0, fmt.Errorf("reading series [%s]: %w", newID, err)
+	}
+	if len(newHistory) > 0 {
+		log.Warnf("[%s] series already found: merging it with [%s]", newID, oldID)
+	}
+
+	if err := st.Import(newID, mergeHistories(oldHistory, newHistory)); err != nil {
+		return 0, fmt.Errorf("writing series [%s]: %w", newID, err)
+	}
+
+	migratedQuotes, err := st.Load(newID)
+	if err != nil {
+		return 0, fmt.Errorf("reading series [%s]: %w", newID, err)
+	}
+
+	if err := migrateEvents(dir, oldID, newID, migratedQuotes); err != nil {
+		return 0, err
+	}
+
+	if err := DeleteSeries(dir, st, oldID); err != nil {
+		return 0, fmt.Errorf("deleting series [%s]: %w", oldID, err)
+	}
+
+	return len(migratedQuotes), nil
+}
+
+// mergeHistories merges the versions of two series, sorted by date and observation time.
+// The versions observed at the same time for the same date are kept only once, from the second history.
+func mergeHistories(history1, history2 []store.Version) []store.Version {
+	type versionKey struct {
+		date     time.Time
+		observed time.Time
+	}
+
+	merged := []store.Version{}
+	index := map[versionKey]int{}
+
+	for _, version := range append(append([]store.Version{}, history1...), history2...) {
+		key := versionKey{date: version.Date.UTC(), observed: version.Observed.UTC()}
+		if i, found := index[key]; found {
+			merged[i] = version
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, version)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if !merged[i].Date.Equal(merged[j].Date) {
+			return merged[i].Date.Before(merged[j].Date)
+		}
+		return merged[i].Observed.Before(merged[j].Observed)
+	})
+	return merged
+}
+
+// migrateEvents merges the events of oldID in the ones of newID, publishing again the adjusted quotes.
+func migrateEvents(dir, oldID, newID string, migratedQuotes []quotes.Quote) error {
+	oldEvents, err := loadEventsFromFile(eventsFilename(dir, oldID))
+	if err != nil {
+		return err
+	}
+	if len(oldEvents) == 0 {
+		return nil
+	}
+
+	newEvents, err := loadEventsFromFile(eventsFilename(dir, newID))
+	if err != nil {
+		return err
+	}
+
+	mergedEvents := mergeEvents(newEvents, oldEvents)
+
+	if err := store.WriteJSON(eventsFilename(dir, newID), mergedEvents); err != nil {
+		return fmt.Errorf("writing events [%s]: %w", newID, err)
+	}
+	if err := store.WriteJSON(adjustedFilename(dir, newID), quotes.Adjust(migratedQuotes, mergedEvents)); err != nil {
+		return fmt.Errorf("writing adjusted quotes [%s]: %w", newID, err)
+	}
+	return nil
+}
+
+// WriteAliases writes the quotes of the securities under their active aliases in the dir output folder,
+// so the URLs of the old IDs keep working during the deprecation period.
+func WriteAliases(dir string, st store.Store, securities []*Security, t time.Time) error {
+	for _, sec := range securities {
+		aliases := sec.ActiveAliases(t)
+		if len(aliases) == 0 {
+			continue
+		}
+
+		quotesData, err := st.Load(sec.ID())
+		if err != nil {
+			return fmt.Errorf("reading series [%s]: %w", sec.ID(), err)
+		}
+		if len(quotesData) == 0 {
+			continue
+		}
+
+		for _, alias := range aliases {
+			if err := store.WriteJSON(aliasFilename(dir, alias.ID), quotesData); err != nil {
+				return fmt.Errorf("writing alias [%s] of [%s]: %w", alias.ID, sec.ID(), err)
+			}
+		}
+	}
+	return nil
+}
+
+func aliasFilename(dir, id string) string {
+	return filepath.Join(dir, "json", id+".json")
+}
+
+// RenameInCatalog sets the id option of the catalog row with the given key (the first field) to newID,
+// adding the alias to its aliases option. The other rows are left untouched.
+func RenameInCatalog(csvBytes []byte, key, newID string, alias Alias) ([]byte, error) {
+	var out bytes.Buffer
+	found := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(csvBytes))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+
+		// the header, the comments and the blank lines are copied as they are
+		if lineNumber == 1 || strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			out.WriteString(line + "\n")
+			continue
+		}
+
+		reader := csv.NewReader(strings.NewReader(line))
+		reader.FieldsPerRecord = -1
+		record, err := reader.Read()
+		if err != nil || record[0] != key {
+			out.WriteString(line + "\n")
+			continue
+		}
+		if found {
+			return nil, fmt.Errorf("line %d: security '%s' found more than once in the catalog", lineNumber, key)
+		}
+		found = true
+
+		if len(record) < 4 {
+			record = append(record, "")
+		}
+
+		options, err := parseOptions(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		aliases := []string{}
+		if options["aliases"] != "" {
+			aliases = append(aliases, options["aliases"])
+		}
+		aliases = append(aliases, alias.String())
+
+		record[3] = setOption(record[3], "id", newID)
+		record[3] = setOption(record[3], "aliases", strings.Join(aliases, ","))
+
+		out.WriteString(formatRecord(record) + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading catalog: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("security '%s' not found in the catalog", key)
+	}
+	return out.Bytes(), nil
+}
+
+// setOption sets an option in the "key=value;key=value" options column, keeping the order of the others.
+func setOption(options, key, value string) string {
+	parts := []string{}
+	set := false
+
+	for _, option := range strings.Split(options, ";") {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		if k, _, _ := strings.Cut(option, "="); strings.TrimSpace(k) == key {
+			option = key + "=" + value
+			set = true
+		}
+		parts = append(parts, option)
+	}
+	if !set {
+		parts = append(parts, key+"="+value)
+	}
+	return strings.Join(parts, ";")
+}
+
+// formatRecord formats a catalog row, with all the fields quoted.
+func formatRecord(record []string) string {
+	fields := make([]string, len(record))
+	for i, field := range record {
+		fields[i] = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+	}
+	return strings.Join(fields, ",")
+}
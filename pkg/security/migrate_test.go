@@ -0,0 +1,71 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	st := store.NewJSON(t.TempDir(), t.TempDir())
+
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	require.Nil(t, st.Replace("OLD", []quotes.Quote{{Date: day(1), Close: 1}, {Date: day(2), Close: 2}}))
+	require.Nil(t, st.Replace("NEW", []quotes.Quote{{Date: day(2), Close: 2.5}, {Date: day(3), Close: 3}}))
+
+	migrated, err := Migrate(t.TempDir(), st, "OLD", "NEW")
+	require.Nil(t, err)
+	assert.Equal(t, 3, migrated)
+
+	// the last observed version wins
+	newQuotes, err := st.Load("NEW")
+	require.Nil(t, err)
+	assert.Equal(t, []quotes.Quote{{Date: day(1), Close: 1}, {Date: day(2), Close: 2.5}, {Date: day(3), Close: 3}}, newQuotes)
+
+	ids, err := st.IDs()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"NEW"}, ids)
+
+	_, err = Migrate(t.TempDir(), st, "OLD", "NEW")
+	assert.NotNil(t, err)
+}
+
+func TestRenameInCatalog(t *testing.T) {
+	csv := `isin,name,loader,options
+# pension funds
+"FP-FonTe-Dinamico.dinamico","Fondo Pensione FonTe - Comparto Dinamico","fonte"
+"FP-Cometa-Crescita.crescita","Fondo Pensione Cometa - Comparto Crescita","cometa","frequency=monthly;aliases=OLD-CRESCITA"
+`
+	until := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	renamed, err := RenameInCatalog([]byte(csv), "FP-FonTe-Dinamico.dinamico", "FonTe-Dinamico", Alias{ID: "FP-FonTe-Dinamico", Until: &until})
+	require.Nil(t, err)
+	assert.Equal(t, `isin,name,loader,options
+# pension funds
+"FP-FonTe-Dinamico.dinamico","Fondo Pensione FonTe - Comparto Dinamico","fonte","id=FonTe-Dinamico;aliases=FP-FonTe-Dinamico:2025-06-30"
+"FP-Cometa-Crescita.crescita","Fondo Pensione Cometa - Comparto Crescita","cometa","frequency=monthly;aliases=OLD-CRESCITA"
+`, string(renamed))
+
+	renamed, err = RenameInCatalog(renamed, "FP-Cometa-Crescita.crescita", "Cometa-Crescita", Alias{ID: "FP-Cometa-Crescita"})
+	require.Nil(t, err)
+
+	securities, err := LoadSecuritiesFromCSV(renamed)
+	require.Nil(t, err)
+	require.Len(t, securities, 2)
+	assert.Equal(t, "Cometa-Crescita", securities[1].ID())
+	assert.Equal(t, []Alias{{ID: "OLD-CRESCITA"}, {ID: "FP-Cometa-Crescita"}}, securities[1].Aliases)
+
+	// the aliases are published until the end of their deprecation day
+	assert.Len(t, securities[0].ActiveAliases(until.Add(23*time.Hour)), 1)
+	assert.Empty(t, securities[0].ActiveAliases(until.AddDate(0, 0, 2)))
+
+	_, err = RenameInCatalog(renamed, "UNKNOWN", "NEW", Alias{ID: "UNKNOWN"})
+	assert.NotNil(t, err)
+}
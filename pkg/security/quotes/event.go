@@ -0,0 +1,84 @@
+package quotes
+
+import (
+	"sort"
+	"time"
+)
+
+// EventType is the type of a corporate Event.
+type EventType string
+
+const (
+	// Split is a share split (or reverse split).
+	Split EventType = "split"
+	// Dividend is a cash distribution.
+	Dividend EventType = "dividend"
+)
+
+// EventLoader is implemented by the QuoteLoaders that can also load corporate events.
+type EventLoader interface {
+	LoadEvents() ([]Event, error)
+}
+
+// Event struct.
+type Event struct {
+	Date time.Time `json:"date"`
+	Type EventType `json:"type"`
+	// Ratio is the number of new shares for each old share of a Split.
+	Ratio float64 `json:"ratio,omitempty"`
+	// Amount is the cash distributed per share by a Dividend.
+	Amount float64 `json:"amount,omitempty"`
+}
+
+// Adjust returns a copy of the quotes with the closes before each event adjusted backward,
+// so that splits and distributions don't show up as losses in the series.
+func Adjust(quotesData []Quote, events []Event) []Quote {
+	adjusted := make([]Quote, len(quotesData))
+	copy(adjusted, quotesData)
+
+	sort.Slice(adjusted, func(i, j int) bool {
+		return adjusted[i].Date.Before(adjusted[j].Date)
+	})
+
+	// the factors are computed on the raw closes, and then applied together
+	factors := make([]float64, len(adjusted))
+	for i := range factors {
+		factors[i] = 1
+	}
+
+	for _, event := range events {
+		// index of the first quote on or after the event date
+		idx := sort.Search(len(adjusted), func(i int) bool {
+			return !adjusted[i].Date.Before(event.Date)
+		})
+		if idx == 0 {
+			continue
+		}
+
+		factor := 1.0
+
+		switch event.Type {
+		case Split:
+			if event.Ratio <= 0 {
+				continue
+			}
+			factor = 1 / event.Ratio
+		case Dividend:
+			prevClose := float64(adjusted[idx-1].Close)
+			if prevClose <= 0 || event.Amount >= prevClose {
+				continue
+			}
+			factor = (prevClose - event.Amount) / prevClose
+		}
+
+		for i := 0; i < idx; i++ {
+			factors[i] *= factor
+		}
+	}
+
+	for i := range adjusted {
+		adjusted[i].Close = float32(float64(adjusted[i].Close) * factors[i])
+	}
+
+	return adjusted
+}
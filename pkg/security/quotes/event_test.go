@@ -0,0 +1,36 @@
+package quotes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdjust(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2023, time.January, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	quotesData := []Quote{
+		{Date: day(4), Close: 50},
+		{Date: day(2), Close: 100},
+		{Date: day(3), Close: 98},
+	}
+	events := []Event{
+		{Date: day(3), Type: Dividend, Amount: 2},
+		{Date: day(4), Type: Split, Ratio: 2},
+	}
+
+	adjusted := Adjust(quotesData, events)
+	require.Len(t, adjusted, 3)
+
+	assert.Equal(t, day(2), adjusted[0].Date)
+	assert.InDelta(t, 49, adjusted[0].Close, 0.0001)
+	assert.InDelta(t, 49, adjusted[1].Close, 0.0001)
+	assert.InDelta(t, 50, adjusted[2].Close, 0.0001)
+
+	// the raw quotes are left untouched
+	assert.Equal(t, float32(50), quotesData[0].Close)
+}
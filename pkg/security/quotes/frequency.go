@@ -0,0 +1,72 @@
+package quotes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/calendar"
+)
+
+// Frequency of a quotes series.
+type Frequency string
+
+const (
+	// Daily series have a quote for every trading day.
+	Daily Frequency = "daily"
+	// Weekly series have a quote for every week.
+	Weekly Frequency = "weekly"
+	// Monthly series have a quote for every month, i.e. pension funds NAVs.
+	Monthly Frequency = "monthly"
+	// Irregular series are published with no fixed schedule.
+	Irregular Frequency = "irregular"
+)
+
+// ParseFrequency parses a Frequency.
+func ParseFrequency(s string) (Frequency, error) {
+	switch f := Frequency(s); f {
+	case Daily, Weekly, Monthly, Irregular:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown frequency \"%s\" - should be one of daily, weekly, monthly, irregular", s)
+}
+
+// ValuationRule defines the date a monthly value is assigned to.
+type ValuationRule string
+
+const (
+	// ProviderDate keeps the date returned by the QuoteLoader.
+	ProviderDate ValuationRule = "provider"
+	// MonthEnd moves the value to the last calendar day of the month.
+	MonthEnd ValuationRule = "month-end"
+	// BusinessMonthEnd moves the value to the last business day of the month.
+	BusinessMonthEnd ValuationRule = "business-month-end"
+)
+
+// ParseValuationRule parses a ValuationRule.
+func ParseValuationRule(s string) (ValuationRule, error) {
+	switch r := ValuationRule(s); r {
+	case ProviderDate, MonthEnd, BusinessMonthEnd:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown valuation rule \"%s\" - should be one of provider, month-end, business-month-end", s)
+}
+
+// Apply returns the valuation date of a value dated in the same month of date.
+// The business days are taken from the Calendar, or are the weekdays if it is nil.
+func (r ValuationRule) Apply(date time.Time, cal *calendar.Calendar) time.Time {
+	if r == "" || r == ProviderDate {
+		return date
+	}
+
+	date = date.UTC()
+	monthEnd := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+
+	if r == BusinessMonthEnd {
+		if cal == nil {
+			cal = calendar.Weekdays
+		}
+		monthEnd = cal.PreviousBusinessDay(monthEnd)
+	}
+
+	return monthEnd
+}
@@ -0,0 +1,43 @@
+package quotes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/calendar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValuationRuleApply(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tt := []struct {
+		name     string
+		rule     ValuationRule
+		date     time.Time
+		calendar *calendar.Calendar
+		expected time.Time
+	}{
+		{name: "provider", rule: ProviderDate, date: day(3, 15), expected: day(3, 15)},
+		{name: "unset", rule: "", date: day(3, 15), expected: day(3, 15)},
+		// March 2024 ends on Sunday 31, and Friday 29 is Good Friday
+		{name: "month-end on a weekend", rule: MonthEnd, date: day(3, 15), expected: day(3, 31)},
+		{name: "business month-end on a weekend", rule: BusinessMonthEnd, date: day(3, 15), expected: day(3, 29)},
+		{name: "business month-end on a weekend and a holiday", rule: BusinessMonthEnd, date: day(3, 15), calendar: calendar.BorsaItaliana, expected: day(3, 28)},
+		// December 31 2024 is a Tuesday, closed on Borsa Italiana
+		{name: "month-end on a holiday", rule: MonthEnd, date: day(12, 2), calendar: calendar.BorsaItaliana, expected: day(12, 31)},
+		{name: "business month-end on a weekday", rule: BusinessMonthEnd, date: day(12, 2), calendar: calendar.Weekdays, expected: day(12, 31)},
+		{name: "business month-end on a holiday", rule: BusinessMonthEnd, date: day(12, 2), calendar: calendar.BorsaItaliana, expected: day(12, 30)},
+		// the value dated on the month-end stays there
+		{name: "business month-end on the month-end", rule: BusinessMonthEnd, date: day(5, 31), calendar: calendar.BorsaItaliana, expected: day(5, 31)},
+		{name: "leap year", rule: MonthEnd, date: day(2, 1), expected: day(2, 29)},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.rule.Apply(tc.date, tc.calendar))
+		})
+	}
+}
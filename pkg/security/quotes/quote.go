@@ -15,4 +15,6 @@ type QuoteLoader interface {
 type Quote struct {
 	Date  time.Time `json:"date"`
 	Close float32   `json:"close"`
+	// Synthetic is set on the quotes not coming from the source, added to fill a gap in the series.
+	Synthetic bool `json:"synthetic,omitempty"`
 }
@@ -0,0 +1,109 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/store"
+)
+
+// Result of the update of a Security.
+type Result struct {
+	ID string `json:"id"`
+	// Loaded is the number of valid quotes returned by the QuoteLoader.
+	Loaded int `json:"loaded"`
+	// Added is the number of quotes not already published.
+	Added int `json:"added"`
+	// Total is the number of published quotes.
+	Total int `json:"total"`
+	// LastDate is the date of the most recent published quote.
+	LastDate *time.Time `json:"lastDate,omitempty"`
+	// Missing are the dates with no quote, according to the expected frequency.
+	Missing []time.Time `json:"missing,omitempty"`
+	// Filled is the number of synthetic quotes added to fill the missing ones.
+	Filled int `json:"filled,omitempty"`
+	// Revisions are the published quotes that changed value.
+	Revisions []Revision `json:"revisions,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Duration  string     `json:"duration"`
+}
+
+// Revision of an already published quote.
+type Revision struct {
+	Date time.Time `json:"date"`
+	Old  float32   `json:"old"`
+	New  float32   `json:"new"`
+}
+
+// Report of an update run.
+type Report struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Results []Result  `json:"results"`
+	// Orphans are the IDs of the published series not found in the catalog.
+	Orphans []string `json:"orphans,omitempty"`
+}
+
+const (
+	reportFilename = "report.json"
+)
+
+// LoadReport loads the Report of the last run from the dir output folder. A missing report has no results.
+func LoadReport(dir string) (Report, error) {
+	var report Report
+
+	reportFilename := filepath.Join(dir, reportFilename)
+
+	b, err := os.ReadFile(reportFilename)
+	if errors.Is(err, os.ErrNotExist) {
+		return report, nil
+	}
+	if err != nil {
+		return report, fmt.Errorf("error reading file [%s]: %s", reportFilename, err.Error())
+	}
+
+	err = json.Unmarshal(b, &report)
+	if err != nil {
+		return report, fmt.Errorf("error unmarshaling file [%s]: %s", reportFilename, err.Error())
+	}
+
+	return report, nil
+}
+
+// MergeReports adds the results of the new Report to the ones of the old Report, replacing the results of the same series.
+// The old results of the series no longer in the catalog of the securities are dropped.
+// It is used when a run updates only some of the securities, i.e. the ones of a loader.
+func MergeReports(oldReport, newReport Report, securities []*Security) Report {
+	catalog := map[string]bool{}
+	for _, sec := range securities {
+		catalog[sec.ID()] = true
+	}
+
+	updated := map[string]bool{}
+	for _, result := range newReport.Results {
+		updated[result.ID] = true
+	}
+
+	merged := newReport
+	merged.Results = append([]Result{}, newReport.Results...)
+	for _, result := range oldReport.Results {
+		if catalog[result.ID] && !updated[result.ID] {
+			merged.Results = append(merged.Results, result)
+		}
+	}
+	return merged
+}
+
+// WriteReport writes the Report of the run in the dir output folder
+func WriteReport(dir string, report Report) error {
+	sort.Slice(report.Results, func(i, j int) bool {
+		return report.Results[i].ID < report.Results[j].ID
+	})
+
+	return store.WriteJSON(filepath.Join(dir, reportFilename), report)
+}
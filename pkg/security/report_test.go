@@ -0,0 +1,43 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeReports(t *testing.T) {
+	btp := testSecurity(t, "IT0005547408", nil)
+	etf := testSecurity(t, "IE00B4L5Y983", nil)
+	run := func(d int) time.Time { return time.Date(2024, time.March, d, 19, 0, 0, 0, time.UTC) }
+
+	dir := t.TempDir()
+
+	previous, err := LoadReport(dir)
+	require.Nil(t, err)
+	assert.Empty(t, previous.Results)
+
+	oldReport := Report{Start: run(1), End: run(1), Results: []Result{
+		{ID: btp.ID(), Added: 1},
+		{ID: etf.ID(), Added: 1},
+		{ID: "RETIRED", Added: 1},
+	}}
+	require.Nil(t, WriteReport(dir, oldReport))
+
+	previous, err = LoadReport(dir)
+	require.Nil(t, err)
+
+	// the job of a loader updates only its securities
+	newReport := Report{Start: run(2), End: run(2), Results: []Result{{ID: btp.ID(), Error: "unreachable"}}}
+	merged := MergeReports(previous, newReport, []*Security{btp, etf})
+
+	assert.Equal(t, run(2), merged.Start)
+	assert.Equal(t, []Result{
+		{ID: btp.ID(), Error: "unreachable"},
+		{ID: etf.ID(), Added: 1},
+	}, merged.Results)
+	// the new report is left untouched
+	assert.Len(t, newReport.Results, 1)
+}
@@ -6,126 +6,446 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"sort"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/calendar"
+	"github.com/enrichman/portfolio-performance/pkg/security/gaps"
 	"github.com/enrichman/portfolio-performance/pkg/security/loaders"
 	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
 	"golang.org/x/exp/maps"
 )
 
-// LoadSecuritiesFromCSV loads all securities from the securities.csv file and returns a slice of corresponding QuoteLoader
-func LoadSecuritiesFromCSV(csvBytes []byte) ([]quotes.QuoteLoader, error) {
+const (
+	// gapsLookbackDays is how far back the missing quotes are looked for.
+	gapsLookbackDays = 365
+)
+
+// LoadSecuritiesFromCSV loads all securities from the securities.csv file and returns a slice of corresponding Security
+func LoadSecuritiesFromCSV(csvBytes []byte) ([]*Security, error) {
+	return LoadCatalogs(csvBytes)
+}
+
+// LoadCatalogs loads the securities of the public catalog together with the ones of the overlay catalogs,
+// that are private unless their visibility option is set. A security already found in a previous catalog is skipped.
+func LoadCatalogs(public []byte, overlays ...[]byte) ([]*Security, error) {
+	securities, err := readCatalog(public, Public)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, overlay := range overlays {
+		overlaySecurities, err := readCatalog(overlay, Private)
+		if err != nil {
+			return nil, err
+		}
+		securities = append(securities, overlaySecurities...)
+	}
+
+	// a security is identified by its ISIN and listing, so the same ISIN can be quoted on different markets
+	registered := map[string]bool{}
+	registeredSecurities := []*Security{}
+
+	for _, sec := range securities {
+		key := sec.ISIN() + "/" + sec.Listing
+		if registered[key] {
+			log.Warnf("security '%s' on listing '%s' already registered", sec.ISIN(), sec.Listing)
+			continue
+		}
+		registered[key] = true
+
+		registeredSecurities = append(registeredSecurities, sec)
+	}
+	securities = registeredSecurities
+
+	for _, warning := range setDefaultListings(securities) {
+		log.Warn(warning.message)
+	}
+
+	// the listings could end up with the same ID of another security
+	ids := map[string]bool{}
+	uniqueSecurities := []*Security{}
+
+	for _, sec := range securities {
+		if ids[sec.ID()] {
+			log.Errorf("Error registering security '%s' (%s): ID already used by another security", sec.ID(), sec.Name())
+			continue
+		}
+		ids[sec.ID()] = true
+		uniqueSecurities = append(uniqueSecurities, sec)
+	}
+
+	// the aliases are published as files too, so they cannot be the ID of another series
+	for _, sec := range uniqueSecurities {
+		aliases := []Alias{}
+		for _, alias := range sec.Aliases {
+			if ids[alias.ID] {
+				log.Errorf("Error registering alias '%s' of security '%s': ID already used by another security", alias.ID, sec.ID())
+				continue
+			}
+			ids[alias.ID] = true
+			aliases = append(aliases, alias)
+		}
+		sec.Aliases = aliases
+
+		log.Infof("security '%s' registered", sec.ID())
+	}
+
+	return uniqueSecurities, nil
+}
+
+// readCatalog reads the securities of a catalog, with the given visibility if not set by their options.
+func readCatalog(csvBytes []byte, visibility Visibility) ([]*Security, error) {
+	records, err := readRecords(csvBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	securities := []*Security{}
+
+	for _, record := range records {
+		sec, err := parseRecord(record.fields, visibility)
+		if err != nil {
+			log.Errorf("Error reading line %d: %s", record.line, err)
+			continue
+		}
+		securities = append(securities, sec)
+	}
+
+	return securities, nil
+}
+
+// catalogRecord is a row of a catalog, with its line number.
+type catalogRecord struct {
+	line   int
+	fields []string
+}
+
+// readRecords reads the rows of a catalog, skipping the header, the comments and the blank lines.
+func readRecords(csvBytes []byte) ([]catalogRecord, error) {
 	// read csv values using csv.Reader
 	csvReader := csv.NewReader(bytes.NewReader(csvBytes))
 	csvReader.Comment = '#'
-	csvReader.FieldsPerRecord = 3
+	csvReader.FieldsPerRecord = -1
 	_, err := csvReader.Read() // skip header line
 	if err != nil {
 		return nil, fmt.Errorf("reading csv: %w", err)
 	}
 
-	data, err := csvReader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("reading csv: %w", err)
+	records := []catalogRecord{}
+	for {
+		fields, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading csv: %w", err)
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		records = append(records, catalogRecord{line: line, fields: fields})
 	}
+}
 
-	securities := make(map[string]quotes.QuoteLoader)
+// parseRecord creates the Security of a catalog row, with the given visibility if not set by its options.
+func parseRecord(fields []string, visibility Visibility) (*Security, error) {
+	if len(fields) != 3 && len(fields) != 4 {
+		return nil, fmt.Errorf("expected 3 or 4 fields, found %d", len(fields))
+	}
 
-	for _, line := range data {
-		isin := line[0]
-		name := line[1]
-		loader := line[2]
+	isin := fields[0]
+	name := fields[1]
+	loader := fields[2]
 
-		quoteLoader, err := loaders.New(loader, name, isin)
+	var options map[string]string
+	if len(fields) == 4 {
+		var err error
+		options, err = parseOptions(fields[3])
 		if err != nil {
-			log.Errorf("Error creating quoteLoader [%s] for ISIN %s (%s): %s", loader, isin, name, err)
-			continue
+			return nil, fmt.Errorf("parsing options for ISIN %s (%s): %w", isin, name, err)
 		}
+	}
 
-		if _, found := securities[quoteLoader.ISIN()]; found {
-			log.Warnf("security '%s' already registered", quoteLoader.ISIN())
-			continue
+	quoteLoader, err := loaders.New(loader, name, isin)
+	if err != nil {
+		return nil, fmt.Errorf("creating quoteLoader [%s] for ISIN %s (%s): %w", loader, isin, name, err)
+	}
+
+	sec, err := newSecurity(loader, isin, quoteLoader, options)
+	if err != nil {
+		return nil, fmt.Errorf("creating security for ISIN %s (%s): %w", isin, name, err)
+	}
+	if _, set := options["visibility"]; !set {
+		sec.Visibility = visibility
+	}
+
+	return sec, nil
+}
+
+// setDefaultListings sets the default listing of every ISIN, published with the ISIN as its ID:
+// the only listing of an ISIN, the one with the default option, or else the first one in the catalog.
+// The private listings are the default only if the ISIN has no public ones.
+// It returns the warnings about the ISINs with no default listing or too many of them.
+func setDefaultListings(securities []*Security) []listingWarning {
+	warnings := []listingWarning{}
+	byISIN := map[string][]*Security{}
+	isins := []string{}
+
+	for _, sec := range securities {
+		if _, found := byISIN[sec.ISIN()]; !found {
+			isins = append(isins, sec.ISIN())
+		}
+		byISIN[sec.ISIN()] = append(byISIN[sec.ISIN()], sec)
+	}
+
+	for _, isin := range isins {
+		listings := byISIN[isin]
+
+		// a private listing cannot take the ID of the public ones, changing their published URL
+		if public := WithVisibility(listings, Public); len(public) > 0 && len(public) < len(listings) {
+			for _, sec := range WithVisibility(listings, Private) {
+				sec.Default = false
+			}
+			listings = public
+		}
+
+		defaults := []*Security{}
+		for _, sec := range listings {
+			if sec.Default {
+				defaults = append(defaults, sec)
+			}
 		}
 
-		securities[quoteLoader.ISIN()] = quoteLoader
-		log.Infof("security '%s' registered", quoteLoader.ISIN())
+		switch {
+		case len(defaults) == 0:
+			if len(listings) > 1 {
+				warnings = append(warnings, listingWarning{
+					isin:    isin,
+					message: fmt.Sprintf("security '%s' has %d listings and no default one: using '%s'", isin, len(listings), listings[0].Listing),
+				})
+			}
+			listings[0].Default = true
+		case len(defaults) > 1:
+			warnings = append(warnings, listingWarning{
+				isin:    isin,
+				message: fmt.Sprintf("security '%s' has %d default listings: using '%s'", isin, len(defaults), defaults[0].Listing),
+			})
+			for _, sec := range defaults[1:] {
+				sec.Default = false
+			}
+		}
 	}
 
-	return maps.Values(securities), nil
+	return warnings
+}
+
+// listingWarning is a problem found setting the default listing of an ISIN.
+type listingWarning struct {
+	isin    string
+	message string
 }
 
-// UpdateQuotes fetches and updates quotes from the QuoteLoader
-func UpdateQuotes(loader quotes.QuoteLoader) {
+// UpdateQuotes fetches and updates quotes from the Security QuoteLoader in the Store, returning the Result of the update.
+// The events are written in the dir output folder.
+func UpdateQuotes(dir string, st store.Store, loader *Security) (result Result) {
 	start := time.Now().In(time.UTC)
+	result = Result{ID: loader.ID()}
 
-	log.Infof("[%s] loading quotes for '%s'", loader.ISIN(), loader.Name())
+	defer func() {
+		result.Duration = time.Since(start).Round(time.Millisecond).String()
+	}()
+
+	log.Infof("[%s] loading quotes for '%s'", loader.ID(), loader.Name())
 
 	newQuotes, err := loader.LoadQuotes()
 	if err != nil {
-		log.Errorf("[%s] error loading quotes: %s", loader.ISIN(), err)
-		return
+		log.Errorf("[%s] error loading quotes: %s", loader.ID(), err)
+		result.Error = fmt.Sprintf("error loading quotes: %s", err)
+		return result
 	}
 	if len(newQuotes) == 0 {
-		log.Warnf("[%s] no quotes found", loader.ISIN())
-		return
+		log.Warnf("[%s] no quotes found", loader.ID())
+		result.Error = "no quotes found"
+		return result
+	}
+
+	newQuotes = validate(newQuotes, loader.ID())
+	if len(newQuotes) == 0 {
+		log.Warnf("[%s] no valid quotes found", loader.ID())
+		result.Error = "no valid quotes found"
+		return result
 	}
+	result.Loaded = len(newQuotes)
 
 	log.Debugf("[%s] new quotes loaded from %s to %s",
-		loader.ISIN(),
+		loader.ID(),
 		newQuotes[0].Date,
 		newQuotes[len(newQuotes)-1].Date,
 	)
 
-	filename := fmt.Sprintf("out/json/%s.json", loader.ISIN())
-	log.Debugf("[%s] loading OLD quotes from '%s'", loader.ISIN(), filename)
+	log.Debugf("[%s] loading OLD quotes", loader.ID())
 
-	oldQuotes, err := loadQuotesFromFile(filename)
+	oldQuotes, err := st.Load(loader.ID())
 	if err != nil {
-		log.Errorf("[%s] error loading quotes: %s", loader.ISIN(), err.Error())
-		return
+		log.Errorf("[%s] error loading quotes: %s", loader.ID(), err.Error())
+		result.Error = err.Error()
+		return result
 	}
 
 	if len(oldQuotes) == 0 {
-		log.Warnf("[%s] no OLD quotes found", loader.ISIN())
+		log.Warnf("[%s] no OLD quotes found", loader.ID())
 	} else {
 		log.Debugf("[%s] found OLD quotes from %s to %s",
-			loader.ISIN(),
+			loader.ID(),
 			oldQuotes[0].Date,
 			oldQuotes[len(oldQuotes)-1].Date,
 		)
 	}
 
-	mergedQuotes := merge(oldQuotes, newQuotes, loader.ISIN())
+	storedQuotes := oldQuotes
+	if loader.Frequency == quotes.Monthly && loader.Valuation != quotes.ProviderDate {
+		oldQuotes = applyValuationRule(oldQuotes, loader.Valuation, loader.Calendar)
+		newQuotes = applyValuationRule(newQuotes, loader.Valuation, loader.Calendar)
+	}
+
+	mergedQuotes, revisions := Merge(oldQuotes, newQuotes, loader.ID())
 	log.Debugf("[%s] merged quotes from %s to %s",
-		loader.ISIN(),
+		loader.ID(),
 		mergedQuotes[0].Date,
 		mergedQuotes[len(mergedQuotes)-1].Date,
 	)
 
-	err = writeQuotesToFile(filename, mergedQuotes)
+	addedQuotes := len(mergedQuotes) - len(oldQuotes)
+	result.LastDate = lastQuoteDate(mergedQuotes)
+
+	result.Missing = gaps.Find(mergedQuotes, loader.Frequency, loader.Valuation, loader.Calendar, start.AddDate(0, 0, -gapsLookbackDays))
+	if len(result.Missing) > 0 {
+		log.Warnf("[%s] missing quotes [%d] since %s", loader.ID(), len(result.Missing), result.Missing[0].Format(time.DateOnly))
+
+		filledQuotes := gaps.Fill(mergedQuotes, result.Missing, loader.Fill)
+		result.Filled = countSynthetic(filledQuotes) - countSynthetic(mergedQuotes)
+		mergedQuotes = filledQuotes
+	}
+
+	err = writeQuotes(st, loader.ID(), storedQuotes, mergedQuotes)
 	if err != nil {
-		log.Errorf("[%s] error writing quotes: %s", loader.ISIN(), err.Error())
-		return
+		log.Errorf("[%s] error writing quotes: %s", loader.ID(), err.Error())
+		result.Error = err.Error()
+		return result
 	}
 
-	addedQuotes := len(mergedQuotes) - len(oldQuotes)
+	result.Added = addedQuotes
+	result.Revisions = revisions
+	result.Total = len(mergedQuotes)
+
 	if addedQuotes == 0 {
-		log.Infof("[%s] no new quotes added", loader.ISIN())
+		log.Infof("[%s] no new quotes added", loader.ID())
 	} else {
 		log.Infof(
 			"[%s] new quotes added [%d] - old [%d] - new [%d]",
-			loader.ISIN(), addedQuotes, len(oldQuotes), len(newQuotes),
+			loader.ID(), addedQuotes, len(oldQuotes), len(newQuotes),
 		)
 	}
 
-	log.Infof("[%s] quotes loaded in %s", loader.ISIN(), time.Since(start))
+	if eventLoader, ok := loader.QuoteLoader.(quotes.EventLoader); ok {
+		updateEvents(dir, loader.ID(), eventLoader, mergedQuotes)
+	}
+
+	log.Infof("[%s] quotes loaded in %s", loader.ID(), time.Since(start))
+
+	return result
+}
+
+// updateEvents fetches and merges the corporate events, and publishes them together with the adjusted quotes
+func updateEvents(dir, isin string, eventLoader quotes.EventLoader, mergedQuotes []quotes.Quote) {
+	newEvents, err := eventLoader.LoadEvents()
+	if err != nil {
+		log.Errorf("[%s] error loading events: %s", isin, err)
+		return
+	}
+
+	filename := eventsFilename(dir, isin)
+
+	oldEvents, err := loadEventsFromFile(filename)
+	if err != nil {
+		log.Errorf("[%s] error loading events: %s", isin, err.Error())
+		return
+	}
+
+	// a series without events has nothing to publish besides its quotes
+	if len(oldEvents) == 0 && len(newEvents) == 0 {
+		return
+	}
+
+	mergedEvents := mergeEvents(oldEvents, newEvents)
+
+	err = store.WriteJSON(filename, mergedEvents)
+	if err != nil {
+		log.Errorf("[%s] error writing events: %s", isin, err.Error())
+		return
+	}
+
+	if addedEvents := len(mergedEvents) - len(oldEvents); addedEvents > 0 {
+		log.Infof("[%s] new events added [%d]", isin, addedEvents)
+	}
+
+	err = store.WriteJSON(adjustedFilename(dir, isin), quotes.Adjust(mergedQuotes, mergedEvents))
+	if err != nil {
+		log.Errorf("[%s] error writing adjusted quotes: %s", isin, err.Error())
+		return
+	}
+}
+
+func eventsFilename(dir, id string) string {
+	return filepath.Join(dir, "json", "events", id+".json")
+}
+
+func adjustedFilename(dir, id string) string {
+	return filepath.Join(dir, "json", "adjusted", id+".json")
+}
+
+// validate drops the quotes without a date, with a non positive close or dated in the future
+func validate(newQuotes []quotes.Quote, isin string) []quotes.Quote {
+	maxDate := time.Now().In(time.UTC).AddDate(0, 0, 1)
+
+	validQuotes := []quotes.Quote{}
+	for _, q := range newQuotes {
+		switch {
+		case q.Date.IsZero():
+			log.Warnf("[%s] skipping quote without date", isin)
+		case q.Date.After(maxDate):
+			log.Warnf("[%s] skipping quote for future date '%v'", isin, q.Date)
+		case math.IsNaN(float64(q.Close)) || math.IsInf(float64(q.Close), 0) || q.Close <= 0:
+			log.Warnf("[%s] skipping quote for date '%v' with invalid value [%v]", isin, q.Date, q.Close)
+		default:
+			validQuotes = append(validQuotes, q)
+		}
+	}
+
+	return validQuotes
 }
 
-func merge(quotes1 []quotes.Quote, quotes2 []quotes.Quote, isin string) []quotes.Quote {
+// applyValuationRule moves the monthly values to their valuation date, keeping the last value found for each month
+func applyValuationRule(monthlyQuotes []quotes.Quote, rule quotes.ValuationRule, cal *calendar.Calendar) []quotes.Quote {
 	quotesMap := map[time.Time]quotes.Quote{}
+	for _, q := range monthlyQuotes {
+		q.Date = rule.Apply(q.Date, cal)
+		quotesMap[q.Date] = q
+	}
+	return maps.Values(quotesMap)
+}
+
+// Merge adds the quotes2 to the quotes1, sorted by date. The quotes with the same date are replaced,
+// returning a Revision if a quote that wasn't synthetic changed value.
+func Merge(quotes1 []quotes.Quote, quotes2 []quotes.Quote, isin string) ([]quotes.Quote, []Revision) {
+	quotesMap := map[time.Time]quotes.Quote{}
+	revisions := []Revision{}
 
 	for _, q := range quotes1 {
 		q.Date = q.Date.UTC()
@@ -135,11 +455,12 @@ func merge(quotes1 []quotes.Quote, quotes2 []quotes.Quote, isin string) []quotes
 	for _, q := range quotes2 {
 		q.Date = q.Date.UTC()
 
-		if oldQuote, found := quotesMap[q.Date]; found {
+		if oldQuote, found := quotesMap[q.Date]; found && !oldQuote.Synthetic {
 			if oldQuote.Close != q.Close {
 				log.Warnf("[%s] quote for date '%v' already exists with different value [old: %v - new: %v]",
 					isin, q.Date, oldQuote.Close, q.Close,
 				)
+				revisions = append(revisions, Revision{Date: q.Date, Old: oldQuote.Close, New: q.Close})
 			}
 		}
 		quotesMap[q.Date] = q
@@ -154,11 +475,58 @@ func merge(quotes1 []quotes.Quote, quotes2 []quotes.Quote, isin string) []quotes
 		return mergedQuotes[i].Date.Before(mergedQuotes[j].Date)
 	})
 
-	return mergedQuotes
+	return mergedQuotes, revisions
 }
 
-func loadQuotesFromFile(filename string) ([]quotes.Quote, error) {
-	oldQuotesByte, err := os.ReadFile(filename)
+func mergeEvents(events1 []quotes.Event, events2 []quotes.Event) []quotes.Event {
+	type eventKey struct {
+		date      time.Time
+		eventType quotes.EventType
+	}
+
+	eventsMap := map[eventKey]quotes.Event{}
+
+	for _, events := range [][]quotes.Event{events1, events2} {
+		for _, e := range events {
+			e.Date = e.Date.UTC()
+			eventsMap[eventKey{e.Date, e.Type}] = e
+		}
+	}
+
+	mergedEvents := maps.Values(eventsMap)
+
+	sort.Slice(mergedEvents, func(i, j int) bool {
+		if mergedEvents[i].Date.Equal(mergedEvents[j].Date) {
+			return mergedEvents[i].Type < mergedEvents[j].Type
+		}
+		return mergedEvents[i].Date.Before(mergedEvents[j].Date)
+	})
+
+	return mergedEvents
+}
+
+// lastQuoteDate returns the date of the most recent quote not added to fill a gap
+func lastQuoteDate(quotesData []quotes.Quote) *time.Time {
+	for i := len(quotesData) - 1; i >= 0; i-- {
+		if !quotesData[i].Synthetic {
+			return &quotesData[i].Date
+		}
+	}
+	return nil
+}
+
+func countSynthetic(quotesData []quotes.Quote) int {
+	count := 0
+	for _, q := range quotesData {
+		if q.Synthetic {
+			count++
+		}
+	}
+	return count
+}
+
+func loadEventsFromFile(filename string) ([]quotes.Event, error) {
+	eventsByte, err := os.ReadFile(filename)
 	if errors.Is(err, os.ErrNotExist) {
 		return nil, nil
 	}
@@ -166,33 +534,40 @@ func loadQuotesFromFile(filename string) ([]quotes.Quote, error) {
 		return nil, fmt.Errorf("error reading file [%s]: %s", filename, err.Error())
 	}
 
-	var oldQuotes []quotes.Quote
-	err = json.Unmarshal(oldQuotesByte, &oldQuotes)
+	var events []quotes.Event
+	err = json.Unmarshal(eventsByte, &events)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshaling file [%s]: %s", filename, err.Error())
 	}
 
-	return oldQuotes, nil
+	return events, nil
 }
 
-func writeQuotesToFile(filename string, quotes []quotes.Quote) error {
-	jsonOutput, err := json.MarshalIndent(quotes, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error marshaling file [%s]: %s", filename, err.Error())
+// writeQuotes writes in the Store only the merged quotes that changed from the stored ones,
+// replacing the whole series if some of the stored quotes were dropped (i.e. moved by the valuation rule)
+func writeQuotes(st store.Store, id string, storedQuotes, mergedQuotes []quotes.Quote) error {
+	mergedMap := map[time.Time]quotes.Quote{}
+	for _, q := range mergedQuotes {
+		mergedMap[q.Date.UTC()] = q
 	}
 
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return fmt.Errorf("error opening file [%s]: %s", filename, err.Error())
-	}
-	err = file.Truncate(0)
-	if err != nil {
-		return fmt.Errorf("error truncating file [%s]: %s", filename, err.Error())
+	storedMap := map[time.Time]quotes.Quote{}
+	for _, q := range storedQuotes {
+		if _, found := mergedMap[q.Date.UTC()]; !found {
+			return st.Replace(id, mergedQuotes)
+		}
+		storedMap[q.Date.UTC()] = q
 	}
 
-	if _, err = file.Write(jsonOutput); err != nil {
-		return fmt.Errorf("error writing to file [%s]: %s", filename, err.Error())
+	changedQuotes := []quotes.Quote{}
+	for _, q := range mergedQuotes {
+		if stored, found := storedMap[q.Date.UTC()]; !found || stored.Close != q.Close || stored.Synthetic != q.Synthetic {
+			changedQuotes = append(changedQuotes, q)
+		}
 	}
 
-	return nil
+	if len(changedQuotes) == 0 {
+		return nil
+	}
+	return st.Put(id, changedQuotes)
 }
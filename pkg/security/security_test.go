@@ -0,0 +1,120 @@
+package security
+
+import (
+	"errors"
+	"math"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/calendar"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLoader is a QuoteLoader returning fixed quotes.
+type fakeLoader struct {
+	isin   string
+	quotes []quotes.Quote
+	err    error
+}
+
+func (f *fakeLoader) Name() string                        { return "Fake " + f.isin }
+func (f *fakeLoader) ISIN() string                        { return f.isin }
+func (f *fakeLoader) LoadQuotes() ([]quotes.Quote, error) { return f.quotes, f.err }
+
+// fakeEventLoader is a fakeLoader returning fixed events too.
+type fakeEventLoader struct {
+	fakeLoader
+	events []quotes.Event
+}
+
+func (f *fakeEventLoader) LoadEvents() ([]quotes.Event, error) { return f.events, nil }
+
+func TestUpdateEvents(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mergedQuotes := []quotes.Quote{{Date: day, Close: 100}, {Date: day.AddDate(0, 0, 1), Close: 50}}
+
+	dir := t.TempDir()
+
+	// nothing is written for a series without events
+	updateEvents(dir, "IT0005547408", &fakeEventLoader{}, mergedQuotes)
+	_, err := os.Stat(eventsFilename(dir, "IT0005547408"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(adjustedFilename(dir, "IT0005547408"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	split := quotes.Event{Date: day.AddDate(0, 0, 1), Type: quotes.Split, Ratio: 2}
+	updateEvents(dir, "IT0005547408", &fakeEventLoader{events: []quotes.Event{split}}, mergedQuotes)
+	events, err := loadEventsFromFile(eventsFilename(dir, "IT0005547408"))
+	require.Nil(t, err)
+	assert.Len(t, events, 1)
+	_, err = os.Stat(adjustedFilename(dir, "IT0005547408"))
+	assert.Nil(t, err)
+}
+
+func TestUpdateQuotesDuration(t *testing.T) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	for name, loader := range map[string]*fakeLoader{
+		"updated": {isin: "IT0005547408", quotes: []quotes.Quote{{Date: day.AddDate(0, 0, -1), Close: 100}, {Date: day, Close: 101}}},
+		"failed":  {isin: "IT0005547408", err: errors.New("unreachable")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sec, err := newSecurity("fake", loader.isin, loader, nil)
+			require.Nil(t, err)
+
+			result := UpdateQuotes(t.TempDir(), store.NewJSON(t.TempDir(), t.TempDir()), sec)
+
+			// the duration is set on every return
+			assert.NotEmpty(t, result.Duration)
+			_, err = time.ParseDuration(result.Duration)
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	newQuotes := []quotes.Quote{
+		{Date: day.AddDate(0, 0, -2), Close: 100},
+		{Close: 100},
+		{Date: day.AddDate(0, 0, 5), Close: 100},
+		{Date: day.AddDate(0, 0, -1), Close: 0},
+		{Date: day.AddDate(0, 0, -1), Close: -1},
+		{Date: day.AddDate(0, 0, -1), Close: float32(math.NaN())},
+		{Date: day.AddDate(0, 0, -1), Close: float32(math.Inf(1))},
+		// tomorrow is still valid, for the markets ahead of UTC
+		{Date: day.AddDate(0, 0, 1), Close: 101},
+	}
+
+	assert.Equal(t, []quotes.Quote{
+		{Date: day.AddDate(0, 0, -2), Close: 100},
+		{Date: day.AddDate(0, 0, 1), Close: 101},
+	}, validate(newQuotes, "IT0005547408"))
+}
+
+func TestApplyValuationRule(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	monthlyQuotes := []quotes.Quote{
+		{Date: day(3, 1), Close: 10},
+		{Date: day(3, 15), Close: 11},
+		{Date: day(12, 2), Close: 12},
+	}
+
+	valued := applyValuationRule(monthlyQuotes, quotes.BusinessMonthEnd, calendar.BorsaItaliana)
+	sort.Slice(valued, func(i, j int) bool { return valued[i].Date.Before(valued[j].Date) })
+
+	// the values of the same month collapse on the last one, moved before Good Friday and the New Year's Eve
+	assert.Equal(t, []quotes.Quote{
+		{Date: day(3, 28), Close: 11},
+		{Date: day(12, 30), Close: 12},
+	}, valued)
+}
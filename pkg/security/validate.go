@@ -0,0 +1,187 @@
+package security
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/loaders"
+)
+
+// onlineWorkers is the number of securities whose quotes are fetched at the same time by an online validation.
+const onlineWorkers = 4
+
+// Severity of a Diagnostic.
+type Severity string
+
+const (
+	// SeverityError is a problem that prevents the security from being loaded or published.
+	SeverityError Severity = "error"
+	// SeverityWarning is a problem that should be fixed, but the security is still loaded.
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found validating a catalog, at a line of the catalog file.
+type Diagnostic struct {
+	Line     int
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d: %s: %s", d.Line, d.Severity, d.Message)
+}
+
+// catalogEntry is a security read from a catalog, with its line number.
+type catalogEntry struct {
+	line int
+	sec  *Security
+}
+
+// ValidateCatalog checks the securities of a catalog, returning the problems found sorted by line.
+// Besides the errors that prevent a security from being loaded it checks the parameters of the loaders,
+// the duplicated securities, IDs and names, and the outputs conflicting on a case-insensitive file system.
+// If online is set the quotes of every active security are fetched too, to check the loaders can reach them.
+func ValidateCatalog(csvBytes []byte, online bool) ([]Diagnostic, error) {
+	records, err := readRecords(csvBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnostics := []Diagnostic{}
+	report := func(line int, severity Severity, format string, args ...any) {
+		diagnostics = append(diagnostics, Diagnostic{Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	entries := []catalogEntry{}
+	listings := map[string]int{}
+	names := map[string]int{}
+
+	for _, record := range records {
+		sec, err := parseRecord(record.fields, Public)
+		if err != nil {
+			report(record.line, SeverityError, "%s", err)
+			continue
+		}
+
+		if validator, ok := sec.QuoteLoader.(loaders.Validator); ok {
+			if err := validator.Validate(); err != nil {
+				report(record.line, SeverityError, "loader [%s]: %s", sec.Loader, err)
+				continue
+			}
+		}
+
+		key := sec.ISIN() + "/" + sec.Listing
+		if line, found := listings[key]; found {
+			report(record.line, SeverityError, "security '%s' on listing '%s' already defined at line %d", sec.ISIN(), sec.Listing, line)
+			continue
+		}
+		listings[key] = record.line
+
+		if line, found := names[sec.Name()]; found {
+			report(record.line, SeverityWarning, "name '%s' already used at line %d", sec.Name(), line)
+		} else {
+			names[sec.Name()] = record.line
+		}
+
+		entries = append(entries, catalogEntry{line: record.line, sec: sec})
+	}
+
+	securities := []*Security{}
+	firstLine := map[string]int{}
+	for _, entry := range entries {
+		securities = append(securities, entry.sec)
+		if _, found := firstLine[entry.sec.ISIN()]; !found {
+			firstLine[entry.sec.ISIN()] = entry.line
+		}
+	}
+
+	for _, warning := range setDefaultListings(securities) {
+		report(firstLine[warning.isin], SeverityWarning, "%s", warning.message)
+	}
+
+	// the IDs are the names of the published files, so they must be unique also ignoring the case
+	ids := map[string]int{}
+	folded := map[string]string{}
+	claim := func(line int, id, owner string) bool {
+		if previous, found := ids[id]; found {
+			report(line, SeverityError, "%s: ID '%s' already used at line %d", owner, id, previous)
+			return false
+		}
+		if other, found := folded[strings.ToLower(id)]; found {
+			report(line, SeverityWarning, "%s: ID '%s' conflicts with '%s' at line %d on case-insensitive file systems", owner, id, other, ids[other])
+		}
+		ids[id] = line
+		folded[strings.ToLower(id)] = id
+		return true
+	}
+
+	valid := []catalogEntry{}
+	for _, entry := range entries {
+		if claim(entry.line, entry.sec.ID(), fmt.Sprintf("security '%s'", entry.sec.Name())) {
+			valid = append(valid, entry)
+		}
+	}
+
+	now := time.Now()
+	for _, entry := range valid {
+		for _, alias := range entry.sec.Aliases {
+			if !alias.ActiveAt(now) {
+				report(entry.line, SeverityWarning, "alias '%s' of '%s' expired on %s and can be removed", alias.ID, entry.sec.ID(), alias.Until.Format(time.DateOnly))
+				continue
+			}
+			claim(entry.line, alias.ID, fmt.Sprintf("alias of '%s'", entry.sec.ID()))
+		}
+	}
+
+	if online {
+		diagnostics = append(diagnostics, checkReachability(valid, now)...)
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+	return diagnostics, nil
+}
+
+// checkReachability fetches the quotes of the active securities, reporting the ones that cannot be loaded.
+func checkReachability(entries []catalogEntry, now time.Time) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, onlineWorkers)
+
+	for _, entry := range entries {
+		if entry.sec.StatusAt(now) == Retired {
+			continue
+		}
+
+		wg.Add(1)
+		go func(entry catalogEntry) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var diagnostic *Diagnostic
+			quotesData, err := entry.sec.LoadQuotes()
+			switch {
+			case err != nil:
+				diagnostic = &Diagnostic{Line: entry.line, Severity: SeverityError, Message: fmt.Sprintf("loading quotes of '%s': %s", entry.sec.ID(), err)}
+			case len(quotesData) == 0:
+				diagnostic = &Diagnostic{Line: entry.line, Severity: SeverityWarning, Message: fmt.Sprintf("no quotes found for '%s'", entry.sec.ID())}
+			}
+
+			if diagnostic != nil {
+				mu.Lock()
+				diagnostics = append(diagnostics, *diagnostic)
+				mu.Unlock()
+			}
+		}(entry)
+	}
+
+	wg.Wait()
+	return diagnostics
+}
@@ -0,0 +1,46 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCatalog(t *testing.T) {
+	csv := `isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana"
+"IT0005547409.MOT","Btp Valore Gn27 Eur Wrong","borsaitaliana"
+"IT0005532723.XXX","Btp Italia Mz28 Eur","borsaitaliana"
+
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana"
+"IE00B4L5Y983.22573329","iShares Core MSCI World","financialtimes","id=it0005547408"
+"FP-Cometa-Reddito.reddito","Fondo Pensione Cometa - Comparto Reddito","unknown"
+"FP-Cometa-Crescita.crescita","Fondo Pensione Cometa - Comparto Crescita","cometa","id=IT0005547408"
+"FP-Cometa-Monetario-Plus.monetario-plus","iShares Core MSCI World","cometa","aliases=CometaMonetario:2020-01-01"
+`
+
+	diagnostics, err := ValidateCatalog([]byte(csv), false)
+	require.Nil(t, err)
+
+	lines := []int{}
+	severities := []Severity{}
+	for _, d := range diagnostics {
+		lines = append(lines, d.Line)
+		severities = append(severities, d.Severity)
+	}
+
+	assert.Equal(t, []int{3, 4, 6, 7, 8, 9, 10, 10}, lines)
+	assert.Equal(t, []Severity{
+		SeverityError,   // wrong check digit
+		SeverityError,   // unknown market
+		SeverityError,   // duplicated listing
+		SeverityWarning, // ID conflicting by case
+		SeverityError,   // unknown loader
+		SeverityError,   // duplicated ID
+		SeverityWarning, // duplicated name
+		SeverityWarning, // expired alias
+	}, severities)
+	assert.Contains(t, diagnostics[0].Message, "check digit")
+	assert.Contains(t, diagnostics[5].Message, "already used at line 2")
+}
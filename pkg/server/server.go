@@ -0,0 +1,421 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	// allFields are the fields of a quote, in their output order.
+	allFields = []string{"date", "close", "synthetic"}
+
+	errBadRequest = errors.New("bad request")
+)
+
+// Server serves the published quotes over HTTP, with the same paths of the static site.
+type Server struct {
+	dir   string
+	store store.Store
+	mux   *http.ServeMux
+
+	manifestMu sync.Mutex
+	manifest   manifest
+}
+
+// manifest is the parsed manifest of the published series, kept until the file changes.
+type manifest struct {
+	info    os.FileInfo
+	entries []manifestEntry
+	// aliases are the entries by the IDs of their aliases
+	aliases map[string]manifestEntry
+}
+
+// manifestEntry is the part of the entries of the manifest used to look up the series.
+type manifestEntry struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+	Path    string `json:"path"`
+	Aliases []struct {
+		ID string `json:"id"`
+	} `json:"aliases"`
+	Identifiers []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"identifiers"`
+}
+
+// New creates a Server for the quotes series of the Store, and the other files published in the dir folder.
+func New(dir string, st store.Store) *Server {
+	s := &Server{
+		dir:   dir,
+		store: st,
+		mux:   http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /json/{file}", s.handleQuotes)
+	s.mux.HandleFunc("GET /lookup/{identifier}", s.handleLookup)
+	s.mux.Handle("GET /", http.FileServer(http.Dir(dir)))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	s.mux.ServeHTTP(w, r)
+	log.Debugf("%s %s in %s", r.Method, r.URL, time.Since(start))
+}
+
+// handleQuotes serves the quotes of a series, filtered and formatted according to the query parameters:
+//   - from, to: the date range, in the YYYY-MM-DD format (both inclusive)
+//   - asof: the series as it was published at the given time, in the YYYY-MM-DD (end of the day) or RFC3339 format
+//   - format: json (default) or csv
+//   - fields: the comma separated fields to return (date, close, synthetic)
+//
+// The aliases of a renamed series listed in the manifest are redirected to its new ID.
+func (s *Server) handleQuotes(w http.ResponseWriter, r *http.Request) {
+	id, found := strings.CutSuffix(r.PathValue("file"), ".json")
+	if !found || id == "" || strings.ContainsAny(id, `/\`) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// the old IDs of the renamed series are redirected to the new ones
+	manifest, err := s.readManifest()
+	if err != nil {
+		log.Errorf("error reading manifest: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if entry, found := manifest.aliases[id]; found {
+		redirect(w, r, entry, http.StatusMovedPermanently)
+		return
+	}
+
+	modified, err := s.store.Modified(id)
+	if err != nil {
+		log.Errorf("[%s] error reading quotes: %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if modified.IsZero() {
+		http.NotFound(w, r)
+		return
+	}
+
+	from, to, err := parseRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	quotesData, err := s.loadQuotes(id, from, to, r)
+	if errors.Is(err, errBadRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Errorf("[%s] error reading quotes: %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	body, contentType, err := render(quotesData, r)
+	if errors.Is(err, errBadRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Errorf("[%s] error rendering quotes: %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	// ServeContent handles the If-None-Match and If-Modified-Since conditional requests
+	name := path.Base(r.URL.Path)
+	http.ServeContent(w, r, name, modified, bytes.NewReader(body))
+}
+
+// handleLookup redirects to the quotes of the series with the given identifier, in the "type:value" format
+// or just its value (i.e. "wkn:A0RPWH" or "A0RPWH"). The ID of a series is an identifier too.
+// If many series match, and only one of them is the default listing, that one is used,
+// otherwise the matching series are listed with a 300 Multiple Choices response.
+func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request) {
+	manifest, err := s.readManifest()
+	if err != nil {
+		log.Errorf("error reading manifest: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	matches := lookup(manifest.entries, r.PathValue("identifier"))
+
+	if len(matches) > 1 {
+		defaults := []manifestEntry{}
+		for _, entry := range matches {
+			if entry.Default {
+				defaults = append(defaults, entry)
+			}
+		}
+		if len(defaults) == 1 {
+			matches = defaults
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		http.NotFound(w, r)
+	case 1:
+		redirect(w, r, matches[0], http.StatusFound)
+	default:
+		b, err := json.MarshalIndent(matches, "", "  ")
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMultipleChoices)
+		w.Write(b)
+	}
+}
+
+// readManifest returns the manifest of the published series. A missing manifest has no entries.
+// The file is parsed again only when it changes, since it is read by every request.
+func (s *Server) readManifest() (manifest, error) {
+	filename := filepath.Join(s.dir, "manifest.json")
+
+	info, err := os.Stat(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest{}, nil
+	}
+	if err != nil {
+		return manifest{}, err
+	}
+
+	s.manifestMu.Lock()
+	defer s.manifestMu.Unlock()
+
+	// the manifest is replaced with a rename when written, so a new file means a new manifest
+	if cached := s.manifest.info; cached != nil && os.SameFile(cached, info) &&
+		cached.ModTime().Equal(info.ModTime()) && cached.Size() == info.Size() {
+		return s.manifest, nil
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	var entries []manifestEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return manifest{}, err
+	}
+
+	aliases := map[string]manifestEntry{}
+	for _, entry := range entries {
+		for _, alias := range entry.Aliases {
+			aliases[alias.ID] = entry
+		}
+	}
+
+	s.manifest = manifest{info: info, entries: entries, aliases: aliases}
+	return s.manifest, nil
+}
+
+// redirect redirects to the quotes of the manifest entry, keeping the query parameters.
+func redirect(w http.ResponseWriter, r *http.Request, entry manifestEntry, code int) {
+	target := "/" + entry.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, code)
+}
+
+// lookup returns the manifest entries with the identifier.
+func lookup(manifest []manifestEntry, identifier string) []manifestEntry {
+	identifierType, value, found := strings.Cut(identifier, ":")
+	if !found {
+		identifierType, value = "", identifier
+	}
+
+	matches := []manifestEntry{}
+	for _, entry := range manifest {
+		match := identifierType == "" && strings.EqualFold(entry.ID, value)
+		for _, alias := range entry.Aliases {
+			match = match || identifierType == "" && strings.EqualFold(alias.ID, value)
+		}
+		for _, id := range entry.Identifiers {
+			if (identifierType == "" || identifierType == id.Type) && strings.EqualFold(id.Value, value) {
+				match = true
+			}
+		}
+		if match {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// loadQuotes returns the quotes of a series in the [from, to) range, as of the time of the asof query parameter if set.
+func (s *Server) loadQuotes(id string, from, to time.Time, r *http.Request) ([]quotes.Quote, error) {
+	asOf := r.URL.Query().Get("asof")
+	if asOf == "" {
+		return s.store.Range(id, from, to)
+	}
+
+	at, err := store.ParseAsOf(asOf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errBadRequest, err)
+	}
+
+	quotesData, err := store.AsOf(s.store, id, at)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []quotes.Quote{}
+	for _, q := range quotesData {
+		if (from.IsZero() || !q.Date.Before(from)) && (to.IsZero() || q.Date.Before(to)) {
+			filtered = append(filtered, q)
+		}
+	}
+	return filtered, nil
+}
+
+// parseRange returns the [from, to) range of the from and to query parameters, both inclusive dates.
+func parseRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	from, err := parseDate(query.Get("from"))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parseDate(query.Get("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	return from, to, nil
+}
+
+// render formats the quotes according to the query parameters.
+func render(quotesData []quotes.Quote, r *http.Request) ([]byte, string, error) {
+	query := r.URL.Query()
+
+	fields := allFields
+	if f := query.Get("fields"); f != "" {
+		fields = []string{}
+		for _, field := range strings.Split(f, ",") {
+			if !slices.Contains(allFields, field) {
+				return nil, "", fmt.Errorf("%w: unknown field \"%s\"", errBadRequest, field)
+			}
+			fields = append(fields, field)
+		}
+	}
+
+	filtered := quotesData
+	if filtered == nil {
+		filtered = []quotes.Quote{}
+	}
+
+	switch format := query.Get("format"); format {
+	case "", "json":
+		if query.Get("fields") == "" {
+			b, err := json.MarshalIndent(filtered, "", "  ")
+			return b, "application/json", err
+		}
+		b, err := json.MarshalIndent(toMaps(filtered, fields), "", "  ")
+		return b, "application/json", err
+	case "csv":
+		b, err := toCSV(filtered, fields)
+		return b, "text/csv; charset=utf-8", err
+	default:
+		return nil, "", fmt.Errorf("%w: unknown format \"%s\"", errBadRequest, format)
+	}
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: wrong date \"%s\" - should be YYYY-MM-DD", errBadRequest, s)
+	}
+	return date, nil
+}
+
+func toMaps(quotesData []quotes.Quote, fields []string) []map[string]any {
+	maps := []map[string]any{}
+	for _, q := range quotesData {
+		m := map[string]any{}
+		for _, field := range fields {
+			switch field {
+			case "date":
+				m["date"] = q.Date
+			case "close":
+				m["close"] = q.Close
+			case "synthetic":
+				m["synthetic"] = q.Synthetic
+			}
+		}
+		maps = append(maps, m)
+	}
+	return maps
+}
+
+func toCSV(quotesData []quotes.Quote, fields []string) ([]byte, error) {
+	var buf bytes.Buffer
+	csvWriter := csv.NewWriter(&buf)
+
+	if err := csvWriter.Write(fields); err != nil {
+		return nil, err
+	}
+
+	for _, q := range quotesData {
+		record := []string{}
+		for _, field := range fields {
+			switch field {
+			case "date":
+				record = append(record, q.Date.Format(time.DateOnly))
+			case "close":
+				record = append(record, strconv.FormatFloat(float64(q.Close), 'f', -1, 32))
+			case "synthetic":
+				record = append(record, strconv.FormatBool(q.Synthetic))
+			}
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	csvWriter.Flush()
+	return buf.Bytes(), csvWriter.Error()
+}
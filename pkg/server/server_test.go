@@ -0,0 +1,160 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testQuotes = `[
+  {"date": "2023-01-26T00:00:00Z", "close": 185.1},
+  {"date": "2023-01-27T00:00:00Z", "close": 185.48},
+  {"date": "2023-01-30T00:00:00Z", "close": 186.48, "synthetic": true}
+]`
+
+func newTestServer(t *testing.T) *Server {
+	dir := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "json"), 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "json", "ISIN.json"), []byte(testQuotes), 0644))
+	return New(dir, store.NewJSON(filepath.Join(dir, "json"), t.TempDir()))
+}
+
+func get(s *Server, url string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleQuotes(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := get(s, "/json/ISIN.json?from=2023-01-27&format=csv&fields=date,close", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "date,close\n2023-01-27,185.48\n2023-01-30,186.48\n", rec.Body.String())
+
+	rec = get(s, "/json/ISIN.json?to=2023-01-26", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"date": "2023-01-26T00:00:00Z", "close": 185.1}]`, rec.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, get(s, "/json/ISIN.json?from=27/01/2023", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, get(s, "/json/ISIN.json?fields=open", nil).Code)
+	assert.Equal(t, http.StatusNotFound, get(s, "/json/UNKNOWN.json", nil).Code)
+}
+
+func TestHandleQuotesCaching(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := get(s, "/json/ISIN.json", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	require.NotEmpty(t, rec.Header().Get("Last-Modified"))
+
+	rec = get(s, "/json/ISIN.json", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = get(s, "/json/ISIN.json?format=csv", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleQuotesAsOf(t *testing.T) {
+	s := newTestServer(t)
+
+	// the quotes published before the history was recorded are observed at the last write of the series
+	written := time.Date(2023, 1, 27, 18, 0, 0, 0, time.UTC)
+	require.Nil(t, os.Chtimes(filepath.Join(s.dir, "json", "ISIN.json"), written, written))
+
+	rec := get(s, "/json/ISIN.json?asof=2023-01-26&to=2023-01-26", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	rec = get(s, "/json/ISIN.json?asof=2023-01-27&to=2023-01-26", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"date": "2023-01-26T00:00:00Z", "close": 185.1}]`, rec.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, get(s, "/json/ISIN.json?asof=yesterday", nil).Code)
+}
+
+func TestHandleLookup(t *testing.T) {
+	s := newTestServer(t)
+
+	manifest := `[
+  {"id": "ISIN", "default": true, "path": "json/ISIN.json", "identifiers": [
+    {"type": "isin", "value": "IE00B4L5Y983"}, {"type": "wkn", "value": "A0RPWH"}]},
+  {"id": "ISIN.XETRA", "default": false, "path": "json/ISIN.XETRA.json", "identifiers": [
+    {"type": "isin", "value": "IE00B4L5Y983"}, {"type": "ticker", "value": "EUNL.DE"}]},
+  {"id": "FUND", "default": true, "path": "json/FUND.json", "identifiers": [{"type": "covip", "value": "1234"}]},
+  {"id": "OTHER", "default": true, "path": "json/OTHER.json", "identifiers": [{"type": "covip", "value": "1234"}]}
+]`
+	require.Nil(t, os.WriteFile(filepath.Join(s.dir, "manifest.json"), []byte(manifest), 0644))
+
+	// the default listing of an ISIN is used
+	rec := get(s, "/lookup/IE00B4L5Y983?format=csv", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/json/ISIN.json?format=csv", rec.Header().Get("Location"))
+
+	rec = get(s, "/lookup/ticker:eunl.de", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/json/ISIN.XETRA.json", rec.Header().Get("Location"))
+
+	rec = get(s, "/lookup/isin.xetra", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/json/ISIN.XETRA.json", rec.Header().Get("Location"))
+
+	rec = get(s, "/lookup/covip:1234", nil)
+	require.Equal(t, http.StatusMultipleChoices, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id": "FUND"`)
+	assert.Contains(t, rec.Body.String(), `"id": "OTHER"`)
+
+	assert.Equal(t, http.StatusNotFound, get(s, "/lookup/wkn:IE00B4L5Y983", nil).Code)
+}
+
+func TestHandleQuotesAlias(t *testing.T) {
+	s := newTestServer(t)
+
+	manifest := `[{"id": "ISIN", "default": true, "path": "json/ISIN.json", "aliases": [{"id": "OLD", "until": "2026-12-31T00:00:00Z"}]}]`
+	require.Nil(t, os.WriteFile(filepath.Join(s.dir, "manifest.json"), []byte(manifest), 0644))
+
+	rec := get(s, "/json/OLD.json?format=csv", nil)
+	require.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/json/ISIN.json?format=csv", rec.Header().Get("Location"))
+
+	rec = get(s, "/lookup/old", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/json/ISIN.json", rec.Header().Get("Location"))
+
+	assert.Equal(t, http.StatusOK, get(s, "/json/ISIN.json", nil).Code)
+}
+
+func TestHandleQuotesAliasReload(t *testing.T) {
+	s := newTestServer(t)
+
+	manifest := `[{"id": "ISIN", "default": true, "path": "json/ISIN.json", "aliases": [{"id": "OLD"}]}]`
+	require.Nil(t, store.WriteFile(filepath.Join(s.dir, "manifest.json"), []byte(manifest)))
+	require.Equal(t, http.StatusMovedPermanently, get(s, "/json/OLD.json", nil).Code)
+	require.Equal(t, http.StatusNotFound, get(s, "/json/OLDER.json", nil).Code)
+
+	// the new manifest is read as soon as it is published
+	manifest = `[{"id": "ISIN", "default": true, "path": "json/ISIN.json", "aliases": [{"id": "OLDER"}]}]`
+	require.Nil(t, store.WriteFile(filepath.Join(s.dir, "manifest.json"), []byte(manifest)))
+	assert.Equal(t, http.StatusNotFound, get(s, "/json/OLD.json", nil).Code)
+	rec := get(s, "/json/OLDER.json", nil)
+	require.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/json/ISIN.json", rec.Header().Get("Location"))
+
+	require.Nil(t, os.Remove(filepath.Join(s.dir, "manifest.json")))
+	assert.Equal(t, http.StatusNotFound, get(s, "/json/OLDER.json", nil).Code)
+}
@@ -0,0 +1,371 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// seriesBucket holds a nested bucket for every series, with the quotes keyed by date.
+	seriesBucket = []byte("series")
+	// historyBucket holds a nested bucket for every series, with the Versions keyed by date and observation time.
+	historyBucket = []byte("history")
+	// modifiedBucket holds the last write time of every series.
+	modifiedBucket = []byte("modified")
+)
+
+// Bolt is the Store keeping the series in an embedded bbolt database.
+// The quotes are keyed by their RFC3339 date, so the range queries and the merges only touch the quotes involved.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens (or creates) the Bolt Store of the database file at path.
+func OpenBolt(path string) (*Bolt, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating directory for database [%s]: %s", path, err.Error())
+	}
+
+	// the database is locked by a single process: fail instead of waiting forever
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening database [%s]: %s", path, err.Error())
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{seriesBucket, historyBucket, modifiedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error initializing database [%s]: %s", path, err.Error())
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+// Load implements Store.
+func (s *Bolt) Load(id string) ([]quotes.Quote, error) {
+	return s.Range(id, time.Time{}, time.Time{})
+}
+
+// Range implements Store.
+func (s *Bolt) Range(id string, from, to time.Time) ([]quotes.Quote, error) {
+	var quotesData []quotes.Quote
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		quotesData, err = readQuotes(tx.Bucket(seriesBucket).Bucket([]byte(id)), from, to)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading series [%s]: %w", id, err)
+	}
+
+	return quotesData, nil
+}
+
+// Put implements Store, writing only the given quotes.
+func (s *Bolt) Put(id string, quotesData []quotes.Quote) error {
+	return s.write(id, false, quotesData)
+}
+
+// Replace implements Store.
+func (s *Bolt) Replace(id string, quotesData []quotes.Quote) error {
+	return s.write(id, true, quotesData)
+}
+
+func (s *Bolt) write(id string, replace bool, quotesData []quotes.Quote) error {
+	observed := now()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(seriesBucket)
+		series := root.Bucket([]byte(id))
+
+		history, err := tx.Bucket(historyBucket).CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+
+		// only the stored quotes with the same dates are read, unless the whole series is replaced
+		oldQuotes := []quotes.Quote{}
+		if series != nil {
+			if replace {
+				oldQuotes, err = readQuotes(series, time.Time{}, time.Time{})
+				if err != nil {
+					return err
+				}
+			} else {
+				for _, q := range quotesData {
+					if v := series.Get(dateKey(q.Date)); v != nil {
+						var old quotes.Quote
+						if err := json.Unmarshal(v, &old); err != nil {
+							return fmt.Errorf("error unmarshaling quote [%s]: %s", dateKey(q.Date), err.Error())
+						}
+						oldQuotes = append(oldQuotes, old)
+					}
+				}
+			}
+
+			if k, _ := history.Cursor().First(); k == nil {
+				storedQuotes, err := readQuotes(series, time.Time{}, time.Time{})
+				if err != nil {
+					return err
+				}
+				modified, err := readModified(tx, id)
+				if err != nil {
+					return err
+				}
+				if err := putVersions(history, initialVersions(storedQuotes, modified)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := putVersions(history, changes(oldQuotes, quotesData, replace, observed)); err != nil {
+			return err
+		}
+
+		if replace && series != nil {
+			if err := root.DeleteBucket([]byte(id)); err != nil {
+				return err
+			}
+		}
+
+		series, err = root.CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+
+		if err := putQuotes(series, quotesData); err != nil {
+			return err
+		}
+		return putModified(tx, id, observed)
+	})
+	if err != nil {
+		return fmt.Errorf("error writing series [%s]: %w", id, err)
+	}
+	return nil
+}
+
+// IDs implements Store.
+func (s *Bolt) IDs() ([]string, error) {
+	ids := []string{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(seriesBucket).ForEachBucket(func(k []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading series: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Modified implements Store.
+func (s *Bolt) Modified(id string) (time.Time, error) {
+	var modified time.Time
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		modified, err = readModified(tx, id)
+		return err
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading series [%s]: %w", id, err)
+	}
+
+	return modified, nil
+}
+
+// History implements Store.
+func (s *Bolt) History(id string) ([]Version, error) {
+	versions := []Version{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		history := tx.Bucket(historyBucket).Bucket([]byte(id))
+		if history == nil {
+			// the series stored before the history was recorded
+			quotesData, err := readQuotes(tx.Bucket(seriesBucket).Bucket([]byte(id)), time.Time{}, time.Time{})
+			if err != nil {
+				return err
+			}
+			modified, err := readModified(tx, id)
+			versions = initialVersions(quotesData, modified)
+			return err
+		}
+
+		return history.ForEach(func(k, v []byte) error {
+			var version Version
+			if err := json.Unmarshal(v, &version); err != nil {
+				return fmt.Errorf("error unmarshaling version [%s]: %s", k, err.Error())
+			}
+			versions = append(versions, version)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading history [%s]: %w", id, err)
+	}
+
+	return versions, nil
+}
+
+// Import implements Store.
+func (s *Bolt) Import(id string, history []Version) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{seriesBucket, historyBucket} {
+			root := tx.Bucket(name)
+			if root.Bucket([]byte(id)) != nil {
+				if err := root.DeleteBucket([]byte(id)); err != nil {
+					return err
+				}
+			}
+		}
+
+		versions, err := tx.Bucket(historyBucket).CreateBucket([]byte(id))
+		if err != nil {
+			return err
+		}
+		if err := putVersions(versions, history); err != nil {
+			return err
+		}
+
+		series, err := tx.Bucket(seriesBucket).CreateBucket([]byte(id))
+		if err != nil {
+			return err
+		}
+		if err := putQuotes(series, asOf(history, time.Time{})); err != nil {
+			return err
+		}
+
+		return putModified(tx, id, now())
+	})
+	if err != nil {
+		return fmt.Errorf("error importing series [%s]: %w", id, err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *Bolt) Delete(id string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{seriesBucket, historyBucket} {
+			root := tx.Bucket(name)
+			if root.Bucket([]byte(id)) != nil {
+				if err := root.DeleteBucket([]byte(id)); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Bucket(modifiedBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting series [%s]: %w", id, err)
+	}
+	return nil
+}
+
+// Close implements Store.
+func (s *Bolt) Close() error {
+	return s.db.Close()
+}
+
+// readModified returns the last write time of a series, zero if never written.
+func readModified(tx *bolt.Tx, id string) (time.Time, error) {
+	var modified time.Time
+
+	v := tx.Bucket(modifiedBucket).Get([]byte(id))
+	if v == nil {
+		return modified, nil
+	}
+	return modified, modified.UnmarshalText(v)
+}
+
+// readQuotes returns the quotes of the series bucket in the [from, to) range.
+func readQuotes(series *bolt.Bucket, from, to time.Time) ([]quotes.Quote, error) {
+	quotesData := []quotes.Quote{}
+	if series == nil {
+		return quotesData, nil
+	}
+
+	c := series.Cursor()
+
+	k, v := c.First()
+	if !from.IsZero() {
+		k, v = c.Seek(dateKey(from))
+	}
+
+	for ; k != nil; k, v = c.Next() {
+		var q quotes.Quote
+		if err := json.Unmarshal(v, &q); err != nil {
+			return nil, fmt.Errorf("error unmarshaling quote [%s]: %s", k, err.Error())
+		}
+		if !to.IsZero() && !q.Date.Before(to) {
+			break
+		}
+		quotesData = append(quotesData, q)
+	}
+
+	return quotesData, nil
+}
+
+func putQuotes(series *bolt.Bucket, quotesData []quotes.Quote) error {
+	for _, q := range quotesData {
+		q.Date = q.Date.UTC()
+
+		v, err := json.Marshal(q)
+		if err != nil {
+			return err
+		}
+		if err := series.Put(dateKey(q.Date), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putVersions(history *bolt.Bucket, versions []Version) error {
+	for _, version := range versions {
+		v, err := json.Marshal(version)
+		if err != nil {
+			return err
+		}
+		if err := history.Put(versionKey(version), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putModified(tx *bolt.Tx, id string, modified time.Time) error {
+	v, err := modified.UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(modifiedBucket).Put([]byte(id), v)
+}
+
+// dateKey returns the key of a quote, sorting as its date.
+func dateKey(date time.Time) []byte {
+	return []byte(date.UTC().Format(time.RFC3339))
+}
+
+// versionKey returns the key of a Version, sorting as its date and observation time.
+func versionKey(version Version) []byte {
+	return []byte(version.Date.UTC().Format(time.RFC3339) + "@" + version.Observed.UTC().Format("2006-01-02T15:04:05.000000000Z"))
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+// Exporting is a Store exporting every series written, with its history, to the JSON Store of the published files.
+// The series missing from the Store are seeded from their export the first time they are read,
+// so the published history is kept when switching backend.
+type Exporting struct {
+	Store
+	export *JSON
+}
+
+// NewExporting returns the Exporting Store writing to s and exporting to export.
+func NewExporting(s Store, export *JSON) *Exporting {
+	return &Exporting{Store: s, export: export}
+}
+
+// Load implements Store.
+func (s *Exporting) Load(id string) ([]quotes.Quote, error) {
+	if err := s.seed(id); err != nil {
+		return nil, err
+	}
+	return s.Store.Load(id)
+}
+
+// Range implements Store.
+func (s *Exporting) Range(id string, from, to time.Time) ([]quotes.Quote, error) {
+	if err := s.seed(id); err != nil {
+		return nil, err
+	}
+	return s.Store.Range(id, from, to)
+}
+
+// Put implements Store.
+func (s *Exporting) Put(id string, quotesData []quotes.Quote) error {
+	if err := s.seed(id); err != nil {
+		return err
+	}
+	if err := s.Store.Put(id, quotesData); err != nil {
+		return err
+	}
+	return s.Export(id)
+}
+
+// Replace implements Store.
+func (s *Exporting) Replace(id string, quotesData []quotes.Quote) error {
+	if err := s.Store.Replace(id, quotesData); err != nil {
+		return err
+	}
+	return s.Export(id)
+}
+
+// Modified implements Store.
+func (s *Exporting) Modified(id string) (time.Time, error) {
+	if err := s.seed(id); err != nil {
+		return time.Time{}, err
+	}
+	return s.Store.Modified(id)
+}
+
+// History implements Store.
+func (s *Exporting) History(id string) ([]Version, error) {
+	if err := s.seed(id); err != nil {
+		return nil, err
+	}
+	return s.Store.History(id)
+}
+
+// Import implements Store.
+func (s *Exporting) Import(id string, history []Version) error {
+	if err := s.Store.Import(id, history); err != nil {
+		return err
+	}
+	return s.Export(id)
+}
+
+// IDs implements Store, returning also the IDs of the series only exported.
+func (s *Exporting) IDs() ([]string, error) {
+	ids, err := s.Store.IDs()
+	if err != nil {
+		return nil, err
+	}
+	exported, err := s.export.IDs()
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	for _, id := range ids {
+		found[id] = true
+	}
+	for _, id := range exported {
+		if !found[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// Delete implements Store.
+func (s *Exporting) Delete(id string) error {
+	if err := s.Store.Delete(id); err != nil {
+		return err
+	}
+	return s.export.Delete(id)
+}
+
+// Export writes the series and its history to their JSON files.
+func (s *Exporting) Export(id string) error {
+	quotesData, err := s.Store.Load(id)
+	if err != nil {
+		return err
+	}
+	history, err := s.Store.History(id)
+	if err != nil {
+		return err
+	}
+
+	// the files are written directly, since the history is the one of the Store
+	if err := WriteJSON(s.export.HistoryFilename(id), history); err != nil {
+		return fmt.Errorf("error exporting series [%s]: %w", id, err)
+	}
+	if err := WriteJSON(s.export.Filename(id), quotesData); err != nil {
+		return fmt.Errorf("error exporting series [%s]: %w", id, err)
+	}
+	return nil
+}
+
+// seed copies the exported series, with its history, in the Store if missing.
+func (s *Exporting) seed(id string) error {
+	modified, err := s.Store.Modified(id)
+	if err != nil || !modified.IsZero() {
+		return err
+	}
+
+	exported, err := s.export.History(id)
+	if err != nil || len(exported) == 0 {
+		return err
+	}
+
+	return s.Store.Import(id, exported)
+}
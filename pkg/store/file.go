@@ -0,0 +1,50 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteJSON writes v as indented JSON to the file, atomically.
+func WriteJSON(filename string, v any) error {
+	jsonOutput, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling file [%s]: %s", filename, err.Error())
+	}
+	return WriteFile(filename, jsonOutput)
+}
+
+// WriteFile writes the data to the file, creating its directory if needed.
+// The data is written to a temporary file renamed at the end, so the file is never read half written.
+func WriteFile(filename string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating directory for file [%s]: %s", filename, err.Error())
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error opening file [%s]: %s", filename, err.Error())
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing to file [%s]: %s", filename, err.Error())
+	}
+	if err = file.Chmod(0644); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing to file [%s]: %s", filename, err.Error())
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("error writing to file [%s]: %s", filename, err.Error())
+	}
+
+	if err = os.Rename(file.Name(), filename); err != nil {
+		return fmt.Errorf("error renaming file [%s]: %s", filename, err.Error())
+	}
+
+	return nil
+}
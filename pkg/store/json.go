@@ -0,0 +1,210 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+// JSON is the Store keeping every series in a pretty-printed JSON file named after its ID, as published in out/json.
+// The Versions of the quotes are kept in a separate folder, with a file for every series, so they are not published.
+type JSON struct {
+	dir        string
+	historyDir string
+}
+
+// NewJSON returns the JSON Store of the dir folder, keeping the Versions of the quotes in the historyDir folder.
+func NewJSON(dir, historyDir string) *JSON {
+	return &JSON{dir: dir, historyDir: historyDir}
+}
+
+// Filename returns the file of a series.
+func (s *JSON) Filename(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// HistoryFilename returns the file of the Versions of a series.
+func (s *JSON) HistoryFilename(id string) string {
+	return filepath.Join(s.historyDir, id+".json")
+}
+
+// Load implements Store.
+func (s *JSON) Load(id string) ([]quotes.Quote, error) {
+	filename := s.Filename(id)
+
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading file [%s]: %s", filename, err.Error())
+	}
+
+	var quotesData []quotes.Quote
+	err = json.Unmarshal(b, &quotesData)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling file [%s]: %s", filename, err.Error())
+	}
+
+	return quotesData, nil
+}
+
+// Range implements Store.
+func (s *JSON) Range(id string, from, to time.Time) ([]quotes.Quote, error) {
+	quotesData, err := s.Load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []quotes.Quote{}
+	for _, q := range quotesData {
+		if inRange(q.Date, from, to) {
+			filtered = append(filtered, q)
+		}
+	}
+	return filtered, nil
+}
+
+// Put implements Store. The whole file is rewritten.
+func (s *JSON) Put(id string, quotesData []quotes.Quote) error {
+	oldQuotes, err := s.Load(id)
+	if err != nil {
+		return err
+	}
+	return s.write(id, oldQuotes, mergeQuotes(oldQuotes, quotesData))
+}
+
+// Replace implements Store.
+func (s *JSON) Replace(id string, quotesData []quotes.Quote) error {
+	oldQuotes, err := s.Load(id)
+	if err != nil {
+		return err
+	}
+
+	sorted := append([]quotes.Quote{}, quotesData...)
+	sortQuotes(sorted)
+
+	return s.write(id, oldQuotes, sorted)
+}
+
+// write writes the new quotes of a series, adding their changes from the old ones to its history.
+func (s *JSON) write(id string, oldQuotes, newQuotes []quotes.Quote) error {
+	versions := changes(oldQuotes, newQuotes, true, now())
+
+	if len(versions) > 0 {
+		history, err := s.History(id)
+		if err != nil {
+			return err
+		}
+
+		history = append(history, versions...)
+		sortVersions(history)
+
+		// the history is written first, so no published change is ever missing from it
+		err = WriteJSON(s.HistoryFilename(id), history)
+		if err != nil {
+			return err
+		}
+	}
+
+	return WriteJSON(s.Filename(id), newQuotes)
+}
+
+// IDs implements Store.
+func (s *JSON) IDs() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory [%s]: %s", s.dir, err.Error())
+	}
+
+	ids := []string{}
+	for _, entry := range entries {
+		if id, found := strings.CutSuffix(entry.Name(), ".json"); found && entry.Type().IsRegular() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// Modified implements Store, returning the modification time of the file.
+func (s *JSON) Modified(id string) (time.Time, error) {
+	info, err := os.Stat(s.Filename(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// History implements Store. The quotes of a series without a history file are returned as initial Versions,
+// observed at the modification time of the series file.
+func (s *JSON) History(id string) ([]Version, error) {
+	filename := s.HistoryFilename(id)
+
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		quotesData, err := s.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		modified, err := s.Modified(id)
+		if err != nil {
+			return nil, err
+		}
+		return initialVersions(quotesData, modified), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading file [%s]: %s", filename, err.Error())
+	}
+
+	var versions []Version
+	err = json.Unmarshal(b, &versions)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling file [%s]: %s", filename, err.Error())
+	}
+
+	return versions, nil
+}
+
+// Import implements Store.
+func (s *JSON) Import(id string, history []Version) error {
+	sorted := append([]Version{}, history...)
+	sortVersions(sorted)
+
+	err := WriteJSON(s.HistoryFilename(id), sorted)
+	if err != nil {
+		return err
+	}
+	return WriteJSON(s.Filename(id), asOf(sorted, time.Time{}))
+}
+
+// Delete implements Store.
+func (s *JSON) Delete(id string) error {
+	for _, filename := range []string{s.Filename(id), s.HistoryFilename(id)} {
+		err := os.Remove(filename)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error removing file [%s]: %s", filename, err.Error())
+		}
+	}
+	return nil
+}
+
+// Close implements Store.
+func (s *JSON) Close() error {
+	return nil
+}
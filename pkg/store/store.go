@@ -0,0 +1,195 @@
+// Package store persists the quotes series.
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+// Store persists the quotes series, identified by their output ID.
+type Store interface {
+	// Load returns all the quotes of a series, sorted by date. A missing series has no quotes.
+	Load(id string) ([]quotes.Quote, error)
+	// Range returns the quotes of a series dated from from (inclusive) until to (exclusive), sorted by date.
+	// A zero from or to leaves the range unbounded.
+	Range(id string, from, to time.Time) ([]quotes.Quote, error)
+	// Put adds the quotes to a series, replacing the quotes with the same date.
+	Put(id string, quotesData []quotes.Quote) error
+	// Replace replaces all the quotes of a series.
+	Replace(id string, quotesData []quotes.Quote) error
+	// IDs returns the IDs of the stored series, sorted.
+	IDs() ([]string, error)
+	// Modified returns the last time a series was written, or a zero time if the series is not found.
+	Modified(id string) (time.Time, error)
+	// History returns every Version of the quotes of a series, sorted by date and observation time.
+	History(id string) ([]Version, error)
+	// Import replaces a series and its history with the given Versions.
+	Import(id string, history []Version) error
+	// Delete removes a series and its history.
+	Delete(id string) error
+	// Close releases the resources of the Store.
+	Close() error
+}
+
+// Version of a quote, with the time it was written in the Store.
+type Version struct {
+	quotes.Quote
+	// Observed is the time the version was written. The quotes already stored before the history
+	// was recorded are observed at the last write of their series, the earliest time they are known to be stored.
+	Observed time.Time `json:"observed"`
+	// Deleted marks a quote removed from the series, i.e. moved by a valuation rule.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// now returns the observation time of the written quotes.
+var now = func() time.Time {
+	return time.Now().UTC()
+}
+
+// Open opens the Store of the given kind: "json" for the directory of JSON files at path, with their history
+// in its history subfolder, or "bolt" for the database file at path.
+func Open(kind, path string) (Store, error) {
+	switch kind {
+	case "json":
+		return NewJSON(path, filepath.Join(path, "history")), nil
+	case "bolt":
+		return OpenBolt(path)
+	default:
+		return nil, fmt.Errorf("unknown store \"%s\" - should be one of json, bolt", kind)
+	}
+}
+
+// AsOf returns the quotes of a series as they were stored at the given time, sorted by date.
+func AsOf(st Store, id string, at time.Time) ([]quotes.Quote, error) {
+	history, err := st.History(id)
+	if err != nil {
+		return nil, err
+	}
+	return asOf(history, at), nil
+}
+
+// ParseAsOf parses a point in time, in the RFC3339 format or as a YYYY-MM-DD date meaning its end of the day (UTC).
+func ParseAsOf(s string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, s); err == nil {
+		return at, nil
+	}
+	date, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("wrong time \"%s\" - should be YYYY-MM-DD or RFC3339", s)
+	}
+	return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// asOf returns the quotes of the latest Versions observed until at, or of all of them if at is zero.
+func asOf(history []Version, at time.Time) []quotes.Quote {
+	latest := map[time.Time]Version{}
+	for _, v := range history {
+		if !at.IsZero() && v.Observed.After(at) {
+			continue
+		}
+		if l, found := latest[v.Date]; !found || !v.Observed.Before(l.Observed) {
+			latest[v.Date] = v
+		}
+	}
+
+	quotesData := []quotes.Quote{}
+	for _, v := range latest {
+		if !v.Deleted {
+			quotesData = append(quotesData, v.Quote)
+		}
+	}
+	sortQuotes(quotesData)
+
+	return quotesData
+}
+
+// changes returns the Versions of the new quotes that are missing or different in the old ones.
+// If deletes is set, the old quotes missing from the new ones are returned as deleted.
+func changes(oldQuotes, newQuotes []quotes.Quote, deletes bool, observed time.Time) []Version {
+	oldMap := map[time.Time]quotes.Quote{}
+	for _, q := range oldQuotes {
+		oldMap[q.Date.UTC()] = q
+	}
+
+	newMap := map[time.Time]bool{}
+	versions := []Version{}
+
+	for _, q := range newQuotes {
+		q.Date = q.Date.UTC()
+		newMap[q.Date] = true
+
+		if old, found := oldMap[q.Date]; !found || old.Close != q.Close || old.Synthetic != q.Synthetic {
+			versions = append(versions, Version{Quote: q, Observed: observed})
+		}
+	}
+
+	if deletes {
+		for date := range oldMap {
+			if !newMap[date] {
+				versions = append(versions, Version{Quote: quotes.Quote{Date: date}, Observed: observed, Deleted: true})
+			}
+		}
+	}
+
+	return versions
+}
+
+// initialVersions returns the Versions of the quotes stored before the history was recorded,
+// observed at the last write of the series.
+func initialVersions(quotesData []quotes.Quote, observed time.Time) []Version {
+	versions := []Version{}
+	for _, q := range quotesData {
+		q.Date = q.Date.UTC()
+		versions = append(versions, Version{Quote: q, Observed: observed.UTC()})
+	}
+	return versions
+}
+
+func sortVersions(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Date.Equal(versions[j].Date) {
+			return versions[i].Observed.Before(versions[j].Observed)
+		}
+		return versions[i].Date.Before(versions[j].Date)
+	})
+}
+
+// inRange reports if the date is in the [from, to) range, where a zero time is unbounded.
+func inRange(date, from, to time.Time) bool {
+	if !from.IsZero() && date.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !date.Before(to) {
+		return false
+	}
+	return true
+}
+
+// mergeQuotes adds the quotes to the old ones, replacing the ones with the same date, and sorts them by date.
+func mergeQuotes(oldQuotes, newQuotes []quotes.Quote) []quotes.Quote {
+	quotesMap := map[time.Time]quotes.Quote{}
+	for _, quotesData := range [][]quotes.Quote{oldQuotes, newQuotes} {
+		for _, q := range quotesData {
+			q.Date = q.Date.UTC()
+			quotesMap[q.Date] = q
+		}
+	}
+
+	merged := make([]quotes.Quote, 0, len(quotesMap))
+	for _, q := range quotesMap {
+		merged = append(merged, q)
+	}
+	sortQuotes(merged)
+
+	return merged
+}
+
+func sortQuotes(quotesData []quotes.Quote) {
+	sort.Slice(quotesData, func(i, j int) bool {
+		return quotesData[i].Date.Before(quotesData[j].Date)
+	})
+}
@@ -0,0 +1,201 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse(time.DateOnly, s)
+	return d
+}
+
+func testStores(t *testing.T) map[string]Store {
+	bolt, err := OpenBolt(filepath.Join(t.TempDir(), "quotes.db"))
+	require.Nil(t, err)
+	t.Cleanup(func() { bolt.Close() })
+
+	return map[string]Store{
+		"json": NewJSON(t.TempDir(), t.TempDir()),
+		"bolt": bolt,
+	}
+}
+
+func TestStore(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			quotesData, err := st.Load("ISIN")
+			require.Nil(t, err)
+			assert.Empty(t, quotesData)
+
+			modified, err := st.Modified("ISIN")
+			require.Nil(t, err)
+			assert.True(t, modified.IsZero())
+
+			require.Nil(t, st.Put("ISIN", []quotes.Quote{
+				{Date: date("2023-01-27"), Close: 2},
+				{Date: date("2023-01-26"), Close: 1},
+			}))
+			require.Nil(t, st.Put("ISIN", []quotes.Quote{
+				{Date: date("2023-01-27"), Close: 2.5},
+				{Date: date("2023-01-30"), Close: 3, Synthetic: true},
+			}))
+
+			quotesData, err = st.Load("ISIN")
+			require.Nil(t, err)
+			assert.Equal(t, []quotes.Quote{
+				{Date: date("2023-01-26"), Close: 1},
+				{Date: date("2023-01-27"), Close: 2.5},
+				{Date: date("2023-01-30"), Close: 3, Synthetic: true},
+			}, quotesData)
+
+			quotesData, err = st.Range("ISIN", date("2023-01-27"), date("2023-01-30"))
+			require.Nil(t, err)
+			assert.Equal(t, []quotes.Quote{{Date: date("2023-01-27"), Close: 2.5}}, quotesData)
+
+			require.Nil(t, st.Replace("ISIN", []quotes.Quote{{Date: date("2023-01-31"), Close: 4}}))
+
+			quotesData, err = st.Load("ISIN")
+			require.Nil(t, err)
+			assert.Equal(t, []quotes.Quote{{Date: date("2023-01-31"), Close: 4}}, quotesData)
+
+			modified, err = st.Modified("ISIN")
+			require.Nil(t, err)
+			assert.False(t, modified.IsZero())
+
+			ids, err := st.IDs()
+			require.Nil(t, err)
+			assert.Equal(t, []string{"ISIN"}, ids)
+
+			require.Nil(t, st.Delete("ISIN"))
+
+			ids, err = st.IDs()
+			require.Nil(t, err)
+			assert.Empty(t, ids)
+
+			history, err := st.History("ISIN")
+			require.Nil(t, err)
+			assert.Empty(t, history)
+		})
+	}
+}
+
+func TestExporting(t *testing.T) {
+	export := NewJSON(t.TempDir(), t.TempDir())
+	require.Nil(t, export.Replace("ISIN", []quotes.Quote{{Date: date("2023-01-26"), Close: 1}}))
+
+	bolt, err := OpenBolt(filepath.Join(t.TempDir(), "quotes.db"))
+	require.Nil(t, err)
+	defer bolt.Close()
+
+	st := NewExporting(bolt, export)
+
+	// the published history is seeded in the database
+	require.Nil(t, st.Put("ISIN", []quotes.Quote{{Date: date("2023-01-27"), Close: 2}}))
+
+	expected := []quotes.Quote{
+		{Date: date("2023-01-26"), Close: 1},
+		{Date: date("2023-01-27"), Close: 2},
+	}
+
+	quotesData, err := bolt.Load("ISIN")
+	require.Nil(t, err)
+	assert.Equal(t, expected, quotesData)
+
+	exported, err := export.Load("ISIN")
+	require.Nil(t, err)
+	assert.Equal(t, expected, exported)
+}
+
+func TestAsOf(t *testing.T) {
+	observed := []time.Time{
+		time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 5, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 9, 10, 0, 0, 0, time.UTC),
+	}
+	defer func(f func() time.Time) { now = f }(now)
+
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			now = func() time.Time { return observed[0] }
+			require.Nil(t, st.Put("ISIN", []quotes.Quote{{Date: date("2024-01-31"), Close: 10}}))
+
+			// restated NAV
+			now = func() time.Time { return observed[1] }
+			require.Nil(t, st.Put("ISIN", []quotes.Quote{
+				{Date: date("2024-01-31"), Close: 11},
+				{Date: date("2024-02-01"), Close: 12},
+			}))
+
+			// moved quote
+			now = func() time.Time { return observed[2] }
+			require.Nil(t, st.Replace("ISIN", []quotes.Quote{
+				{Date: date("2024-01-31"), Close: 11},
+				{Date: date("2024-02-02"), Close: 12},
+			}))
+
+			history, err := st.History("ISIN")
+			require.Nil(t, err)
+			assert.Len(t, history, 5)
+
+			quotesData, err := AsOf(st, "ISIN", observed[0].Add(time.Hour))
+			require.Nil(t, err)
+			assert.Equal(t, []quotes.Quote{{Date: date("2024-01-31"), Close: 10}}, quotesData)
+
+			quotesData, err = AsOf(st, "ISIN", observed[1])
+			require.Nil(t, err)
+			assert.Equal(t, []quotes.Quote{
+				{Date: date("2024-01-31"), Close: 11},
+				{Date: date("2024-02-01"), Close: 12},
+			}, quotesData)
+
+			quotesData, err = AsOf(st, "ISIN", observed[2])
+			require.Nil(t, err)
+			assert.Equal(t, []quotes.Quote{
+				{Date: date("2024-01-31"), Close: 11},
+				{Date: date("2024-02-02"), Close: 12},
+			}, quotesData)
+
+			quotesData, err = AsOf(st, "ISIN", observed[0].Add(-time.Hour))
+			require.Nil(t, err)
+			assert.Empty(t, quotesData)
+		})
+	}
+}
+
+func TestJSONHistoryBeforeRecording(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+
+	dir, historyDir := t.TempDir(), t.TempDir()
+	st := NewJSON(dir, historyDir)
+
+	// a series published before the history was recorded
+	written := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	require.Nil(t, WriteJSON(st.Filename("ISIN"), []quotes.Quote{{Date: date("2024-01-31"), Close: 10}}))
+	require.Nil(t, os.Chtimes(st.Filename("ISIN"), written, written))
+
+	observed := time.Date(2024, 2, 5, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return observed }
+	require.Nil(t, st.Put("ISIN", []quotes.Quote{{Date: date("2024-01-31"), Close: 11}}))
+
+	// the stored quotes are observed at the last write of the series, not at a zero time
+	history, err := st.History("ISIN")
+	require.Nil(t, err)
+	assert.Equal(t, []Version{
+		{Quote: quotes.Quote{Date: date("2024-01-31"), Close: 10}, Observed: written},
+		{Quote: quotes.Quote{Date: date("2024-01-31"), Close: 11}, Observed: observed},
+	}, history)
+
+	// the history is kept out of the published folder
+	assert.FileExists(t, filepath.Join(historyDir, "ISIN.json"))
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ISIN.json", entries[0].Name())
+}
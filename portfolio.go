@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/portfolio"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/enrichman/portfolio-performance/pkg/security/quotes"
+)
+
+const (
+	// defaultBaseURL is the URL the quotes are published at.
+	defaultBaseURL = "https://ananni13.github.io/portfolio-performance"
+
+	portfolioUsage = `Usage: portfolio-performance portfolio <command> [flags] <file.xml>
+
+Commands:
+  link      set the JSON feed of the portfolio securities found in the catalog
+  missing   list the portfolio securities not found in the catalog
+  prices    add the published quotes to the historical prices of the portfolio securities
+`
+)
+
+func portfolioCmd(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, portfolioUsage)
+		return errors.New("missing portfolio command")
+	}
+
+	switch args[0] {
+	case "link":
+		return portfolioLinkCmd(args[1:])
+	case "missing":
+		return portfolioMissingCmd(args[1:])
+	case "prices":
+		return portfolioPricesCmd(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, portfolioUsage)
+		return fmt.Errorf("unknown portfolio command \"%s\"", args[0])
+	}
+}
+
+// parsePortfolioFlags parses the flags of a portfolio command, returning the portfolio file.
+func parsePortfolioFlags(flags *flag.FlagSet, args []string) (string, error) {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: portfolio-performance portfolio %s [flags] <file.xml>\n", flags.Name())
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return "", errors.New("missing portfolio file")
+	}
+	return flags.Arg(0), nil
+}
+
+// catalogIndex indexes the securities of the catalog by their identifiers, upper case, to match the portfolio securities.
+// The ISIN, COVIP and WKN identifiers are shared by the listings of a security, so they select the default listing,
+// while the IDs, the provider codes and the tickers select their own listing.
+func catalogIndex(catalog []*security.Security) map[string]*security.Security {
+	index := map[string]*security.Security{}
+	for _, sec := range catalog {
+		if !sec.Default {
+			continue
+		}
+		for _, identifier := range sec.Identifiers {
+			switch identifier.Type {
+			case security.ISINIdentifier, security.COVIPIdentifier, security.WKNIdentifier:
+				index[strings.ToUpper(identifier.Value)] = sec
+			}
+		}
+	}
+
+	for _, sec := range catalog {
+		codes := []string{sec.ID()}
+		for _, identifier := range sec.Identifiers {
+			switch identifier.Type {
+			case security.ProviderIdentifier, security.TickerIdentifier:
+				codes = append(codes, identifier.Value)
+			}
+		}
+		for _, code := range codes {
+			if _, found := index[strings.ToUpper(code)]; !found {
+				index[strings.ToUpper(code)] = sec
+			}
+		}
+	}
+	return index
+}
+
+// lookup returns the catalog security matching the ISIN, the WKN or the ticker symbol of the portfolio security, in this order.
+// The pension funds with no ISIN can be matched by their COVIP code or their catalog ID in the WKN or ticker symbol.
+func lookup(index map[string]*security.Security, sec *portfolio.Security) (*security.Security, bool) {
+	for _, code := range []string{sec.ISIN(), sec.WKN(), sec.TickerSymbol()} {
+		if code == "" {
+			continue
+		}
+		if catalogSec, found := index[code]; found {
+			return catalogSec, true
+		}
+	}
+	return nil, false
+}
+
+func portfolioLinkCmd(args []string) error {
+	var catalogCfg catalogConfig
+
+	flags := flag.NewFlagSet("link", flag.ExitOnError)
+	baseURL := flags.String("base-url", defaultBaseURL, "URL the quotes are published at")
+	write := flags.Bool("write", false, "save the changes in the portfolio file, instead of only listing them")
+	force := flags.Bool("force", false, "replace the feeds already configured with a different provider")
+	catalogCfg.register(flags)
+	path, err := parsePortfolioFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	file, err := portfolio.Open(path)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := catalogCfg.load()
+	if err != nil {
+		return err
+	}
+	index := catalogIndex(catalog)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSTATUS")
+
+	changed := 0
+	for _, sec := range file.Securities() {
+		catalogSec, found := lookup(index, sec)
+		if !found {
+			continue
+		}
+
+		url := fmt.Sprintf("%s/json/%s.json", strings.TrimSuffix(*baseURL, "/"), catalogSec.ID())
+
+		var status string
+		switch {
+		case catalogSec.Visibility == security.Private:
+			status = "skipped, private security not published"
+		case sec.HasJSONFeed(url):
+			status = "already linked"
+		case sec.Feed() != "" && sec.Feed() != portfolio.JSONFeed && !*force:
+			status = fmt.Sprintf("skipped, feed %s already configured (use -force to replace it)", sec.Feed())
+		default:
+			sec.SetJSONFeed(url)
+			status = "linked to " + url
+			changed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", catalogSec.ID(), sec.Name(), status)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !*write {
+		log.Infof("%d securities to link - run with -write to save them in '%s'", changed, path)
+		return nil
+	}
+	if changed == 0 {
+		return nil
+	}
+
+	if err := saveWithBackup(file, path); err != nil {
+		return err
+	}
+	log.Infof("%d securities linked in '%s'", changed, path)
+	return nil
+}
+
+func portfolioPricesCmd(args []string) error {
+	var storeCfg storeConfig
+	var catalogCfg catalogConfig
+
+	flags := flag.NewFlagSet("prices", flag.ExitOnError)
+	write := flags.Bool("write", false, "save the prices in the portfolio file, instead of only listing the changes")
+	synthetic := flags.Bool("synthetic", false, "add also the synthetic quotes filling the gaps of the series")
+	storeCfg.register(flags)
+	catalogCfg.register(flags)
+	path, err := parsePortfolioFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	file, err := portfolio.Open(path)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := catalogCfg.load()
+	if err != nil {
+		return err
+	}
+	index := catalogIndex(catalog)
+
+	outputs, err := openOutputs(storeCfg, catalogCfg.privateDir, catalog)
+	if err != nil {
+		return err
+	}
+	defer closeOutputs(outputs)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tADDED\tREVISED")
+
+	changed := 0
+	for _, sec := range file.Securities() {
+		catalogSec, found := lookup(index, sec)
+		if !found {
+			continue
+		}
+
+		prices, err := sec.Prices()
+		if err != nil {
+			return err
+		}
+
+		storedQuotes, err := outputOf(outputs, catalog, catalogSec.ID()).store.Load(catalogSec.ID())
+		if err != nil {
+			return err
+		}
+
+		// the prices of Portfolio Performance have no time: the last quote of every day is used
+		newQuotes := []quotes.Quote{}
+		for _, q := range storedQuotes {
+			if q.Synthetic && !*synthetic {
+				continue
+			}
+			q.Date = q.Date.UTC().Truncate(24 * time.Hour)
+			q.Synthetic = false
+
+			if len(newQuotes) > 0 && newQuotes[len(newQuotes)-1].Date.Equal(q.Date) {
+				newQuotes[len(newQuotes)-1] = q
+				continue
+			}
+			newQuotes = append(newQuotes, q)
+		}
+
+		mergedQuotes, revisions := security.Merge(prices, newQuotes, catalogSec.ID())
+
+		added := len(mergedQuotes) - len(prices)
+		if added == 0 && len(revisions) == 0 {
+			continue
+		}
+
+		sec.SetPrices(mergedQuotes)
+		changed++
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", catalogSec.ID(), sec.Name(), added, len(revisions))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !*write {
+		log.Infof("%d securities to update - run with -write to save them in '%s'", changed, path)
+		return nil
+	}
+	if changed == 0 {
+		return nil
+	}
+
+	if err := saveWithBackup(file, path); err != nil {
+		return err
+	}
+	log.Infof("%d securities updated in '%s'", changed, path)
+	return nil
+}
+
+// saveWithBackup saves the portfolio file at path, after a backup of the current one.
+func saveWithBackup(file *portfolio.File, path string) error {
+	backup, err := portfolio.Backup(path)
+	if err != nil {
+		return err
+	}
+	log.Infof("portfolio backed up to '%s'", backup)
+
+	return file.Save(path)
+}
+
+func portfolioMissingCmd(args []string) error {
+	var catalogCfg catalogConfig
+
+	flags := flag.NewFlagSet("missing", flag.ExitOnError)
+	retired := flags.Bool("retired", false, "include the securities marked as inactive")
+	catalogCfg.register(flags)
+	path, err := parsePortfolioFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	file, err := portfolio.Open(path)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := catalogCfg.load()
+	if err != nil {
+		return err
+	}
+	index := catalogIndex(catalog)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ISIN\tNAME")
+
+	for _, sec := range file.Securities() {
+		if sec.Retired() && !*retired {
+			continue
+		}
+		if _, found := lookup(index, sec); found {
+			continue
+		}
+
+		isin := sec.ISIN()
+		if isin == "" {
+			isin = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", isin, sec.Name())
+	}
+
+	return w.Flush()
+}
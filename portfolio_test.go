@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogIndex(t *testing.T) {
+	csv := `isin,name,loader,options
+"IE00B4L5Y983.22573329","iShares Core MSCI World","financialtimes","wkn=A0RPWH;ticker=SWDA.MI"
+"IE00B4L5Y983.XETRA","iShares Core MSCI World","borsaitaliana","listing=XETRA;ticker=EUNL.DE"
+"FP-FonTe-Conservativo.garantito","Fondo Pensione Fon.Te. - Comparto Conservativo","fonte","covip=1234"
+`
+	catalog, err := security.LoadSecuritiesFromCSV([]byte(csv))
+	require.Nil(t, err)
+	index := catalogIndex(catalog)
+
+	tt := []struct {
+		code string
+		id   string
+	}{
+		{code: "IE00B4L5Y983", id: "IE00B4L5Y983"},
+		{code: "A0RPWH", id: "IE00B4L5Y983"},
+		{code: "SWDA.MI", id: "IE00B4L5Y983"},
+		{code: "EUNL.DE", id: "IE00B4L5Y983.XETRA"},
+		{code: "IE00B4L5Y983.XETRA", id: "IE00B4L5Y983.XETRA"},
+		{code: "1234", id: "FP-FonTe-Conservativo"},
+		{code: "FP-FONTE-CONSERVATIVO", id: "FP-FonTe-Conservativo"},
+		{code: "FP-FONTE-CONSERVATIVO.GARANTITO", id: "FP-FonTe-Conservativo"},
+	}
+
+	for _, tc := range tt {
+		sec, found := index[tc.code]
+		require.True(t, found, tc.code)
+		assert.Equal(t, tc.id, sec.ID(), tc.code)
+	}
+	assert.NotContains(t, index, "LU0000000000")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+)
+
+func pruneCmd(args []string) error {
+	var storeCfg storeConfig
+	var catalogCfg catalogConfig
+
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	deleteOrphans := flags.Bool("delete", false, "delete the orphaned series, instead of only listing them")
+	storeCfg.register(flags)
+	catalogCfg.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	catalog, err := catalogCfg.load()
+	if err != nil {
+		return err
+	}
+
+	outputs, err := openOutputs(storeCfg, catalogCfg.privateDir, catalog)
+	if err != nil {
+		return err
+	}
+	defer closeOutputs(outputs)
+
+	for _, out := range outputs {
+		if err := prune(out, security.WithVisibility(catalog, out.visibility), *deleteOrphans); err != nil {
+			return fmt.Errorf("pruning %s securities: %w", out.visibility, err)
+		}
+	}
+	return nil
+}
+
+// prune lists, or deletes, the series in the output not found in the catalog.
+func prune(out output, catalog []*security.Security, deleteOrphans bool) error {
+	orphans, err := security.Orphans(out.store, catalog)
+	if err != nil {
+		return fmt.Errorf("finding orphaned series: %w", err)
+	}
+
+	for _, id := range orphans {
+		fmt.Println(id)
+	}
+
+	if !deleteOrphans {
+		log.Infof("%d orphaned series found in '%s' - run with -delete to remove them", len(orphans), out.dir)
+		return nil
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	health, err := security.LoadHealth(out.dir)
+	if err != nil {
+		return fmt.Errorf("loading health: %w", err)
+	}
+
+	for _, id := range orphans {
+		if err := security.DeleteSeries(out.dir, out.store, id); err != nil {
+			return fmt.Errorf("deleting series [%s]: %w", id, err)
+		}
+		health.Remove(id)
+	}
+
+	if err := security.WriteHealth(out.dir, health); err != nil {
+		return fmt.Errorf("writing health: %w", err)
+	}
+
+	log.Infof("%d orphaned series deleted from '%s'", len(orphans), out.dir)
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/enrichman/portfolio-performance/pkg/server"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+)
+
+func serveCmd(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	dir := flags.String("dir", "out", "folder of the published quotes")
+	refresh := flags.Duration("refresh", 0, "interval of the background quotes update (i.e. 24h), disabled if 0")
+	var storeCfg storeConfig
+	var catalogCfg catalogConfig
+	storeCfg.register(flags)
+	catalogCfg.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var st store.Store
+
+	if *refresh > 0 {
+		if *dir != publicDir {
+			return errors.New("the background update can only be used with the default \"out\" folder")
+		}
+
+		loaders, err := catalogCfg.load()
+		if err != nil {
+			return err
+		}
+
+		outputs, err := openOutputs(storeCfg, catalogCfg.privateDir, loaders)
+		if err != nil {
+			return err
+		}
+		defer closeOutputs(outputs)
+
+		// only the public output is served, the private securities are updated but never published
+		st = outputs[0].store
+
+		// the stores are closed only after the running update completes, since it could be still writing to them
+		refreshed := make(chan struct{})
+		defer func() {
+			stop()
+			<-refreshed
+		}()
+
+		go func() {
+			defer close(refreshed)
+			refreshQuotes(ctx, outputs, loaders, *refresh)
+		}()
+	} else {
+		var err error
+		st, err = storeCfg.open(filepath.Join(*dir, "json"), publicHistoryDir)
+		if err != nil {
+			return fmt.Errorf("opening store: %w", err)
+		}
+		defer st.Close()
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(*dir, st),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Errorf("shutting down server: %s", err)
+		}
+	}()
+
+	log.Infof("serving quotes from '%s' on %s", *dir, *addr)
+
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// refreshQuotes updates the quotes at every interval, until the context is done.
+func refreshQuotes(ctx context.Context, outputs []output, loaders []*security.Security, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Info("refreshing quotes")
+			if err := updateOutputs(outputs, loaders, loaders); err != nil {
+				log.Errorf("refreshing quotes: %s", err)
+			}
+		}
+	}
+}
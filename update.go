@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/notify"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/enrichman/portfolio-performance/pkg/store"
+)
+
+// storeConfig selects the Store of the quotes series.
+type storeConfig struct {
+	kind string
+	path string
+}
+
+func (c *storeConfig) register(flags *flag.FlagSet) {
+	flags.StringVar(&c.kind, "store", "json", "store of the quotes series: json (the published files) or bolt (embedded database)")
+	flags.StringVar(&c.path, "db", "data/quotes.db", "database file of the bolt store")
+}
+
+// open opens the configured Store. The series of a database are exported to the JSON files of the jsonDir folder,
+// and their history to the historyDir folder.
+func (c *storeConfig) open(jsonDir, historyDir string) (store.Store, error) {
+	if c.kind == "json" {
+		return store.NewJSON(jsonDir, historyDir), nil
+	}
+
+	st, err := store.Open(c.kind, c.path)
+	if err != nil {
+		return nil, err
+	}
+	return store.NewExporting(st, store.NewJSON(jsonDir, historyDir)), nil
+}
+
+func updateCmd(args []string) error {
+	var storeCfg storeConfig
+	var catalogCfg catalogConfig
+
+	flags := flag.NewFlagSet("update", flag.ExitOnError)
+	tags := flags.String("tag", "", "update only the securities with one of the comma separated tags")
+	storeCfg.register(flags)
+	catalogCfg.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	loaders, err := catalogCfg.load()
+	if err != nil {
+		return err
+	}
+
+	outputs, err := openOutputs(storeCfg, catalogCfg.privateDir, loaders)
+	if err != nil {
+		return err
+	}
+	defer closeOutputs(outputs)
+
+	log.Infof("loaded %d securities", len(loaders))
+
+	catalog := loaders
+	if *tags != "" {
+		var selected []string
+		loaders, selected, err = withTags(catalog, *tags)
+		if err != nil {
+			return err
+		}
+		log.Infof("updating %d securities with tag %s", len(loaders), strings.Join(selected, ","))
+	}
+
+	return updateOutputs(outputs, catalog, loaders)
+}
+
+// withTags returns the securities with one of the comma separated tags, normalised like the tags of the catalog.
+func withTags(catalog []*security.Security, value string) ([]*security.Security, []string, error) {
+	tags, err := security.ParseTags(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tags) == 0 {
+		return nil, nil, fmt.Errorf("no tags in \"%s\"", value)
+	}
+
+	// a tag no security has is likely a typo, that would silently skip its securities
+	for _, tag := range tags {
+		if len(security.WithTags(catalog, []string{tag})) == 0 {
+			return nil, nil, fmt.Errorf("no securities with tag %s", tag)
+		}
+	}
+
+	return security.WithTags(catalog, tags), tags, nil
+}
+
+// updateOutputs updates the loaders in the output of their visibility.
+func updateOutputs(outputs []output, catalog, loaders []*security.Security) error {
+	for _, out := range outputs {
+		outLoaders := security.WithVisibility(loaders, out.visibility)
+		if len(outLoaders) == 0 {
+			continue
+		}
+
+		if _, err := update(out, security.WithVisibility(catalog, out.visibility), outLoaders); err != nil {
+			return fmt.Errorf("updating %s securities: %w", out.visibility, err)
+		}
+	}
+	return nil
+}
+
+// update fetches the quotes of the loaders in the Store of the output, and writes in its folder the report and health of the run,
+// and the manifest of the catalog.
+func update(out output, catalog, loaders []*security.Security) (security.Report, error) {
+	st := out.store
+
+	// moving the default listing of an ISIN would mix the prices of two listings in the same series
+	if err := security.CheckDefaultListings(out.dir, st, catalog); err != nil {
+		return security.Report{}, err
+	}
+
+	report := security.Report{Start: time.Now().In(time.UTC)}
+
+	// the retired securities are not updated, keeping their history frozen
+	active := security.ActiveSecurities(loaders, report.Start)
+	if retired := len(loaders) - len(active); retired > 0 {
+		log.Infof("skipping %d retired securities", retired)
+	}
+	loaders = active
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for _, loader := range loaders {
+		wg.Add(1)
+
+		loader := loader
+
+		go func() {
+			defer wg.Done()
+			result := security.UpdateQuotes(out.dir, st, loader)
+
+			mu.Lock()
+			report.Results = append(report.Results, result)
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	report.End = time.Now().In(time.UTC)
+
+	// the old IDs of the renamed securities are still published during their deprecation period
+	err := security.WriteAliases(out.dir, st, catalog, report.End)
+	if err != nil {
+		return report, fmt.Errorf("writing aliases: %w", err)
+	}
+
+	orphans, err := security.Orphans(st, catalog)
+	if err != nil {
+		return report, fmt.Errorf("finding orphaned series: %w", err)
+	}
+	if len(orphans) > 0 {
+		log.Warnf("%d published series not in the catalog - run the prune command to remove them", len(orphans))
+	}
+	report.Orphans = orphans
+
+	// a run can update only some of the securities (i.e. the daemon jobs of a loader), so the results of the others are kept
+	previous, err := security.LoadReport(out.dir)
+	if err != nil {
+		return report, fmt.Errorf("loading report: %w", err)
+	}
+
+	err = security.WriteReport(out.dir, security.MergeReports(previous, report, catalog))
+	if err != nil {
+		return report, fmt.Errorf("writing report: %w", err)
+	}
+
+	health, err := security.LoadHealth(out.dir)
+	if err != nil {
+		return report, fmt.Errorf("loading health: %w", err)
+	}
+
+	health.Update(report, loaders)
+
+	err = security.WriteHealth(out.dir, health)
+	if err != nil {
+		return report, fmt.Errorf("writing health: %w", err)
+	}
+
+	notifier, err := notify.FromEnv()
+	if err != nil {
+		return report, fmt.Errorf("configuring notifier: %w", err)
+	}
+
+	// the notifications can be published (i.e. as GitHub issues), so the private securities are not notified
+	if notifier != nil && out.visibility == security.Public {
+		err = notifier.Notify(report, health)
+		if err != nil {
+			log.Errorf("notifying run results: %s", err)
+		}
+	}
+
+	err = security.WriteManifest(out.dir, st, catalog)
+	if err != nil {
+		return report, fmt.Errorf("writing manifest: %w", err)
+	}
+
+	err = security.WriteGroups(out.dir, st, catalog)
+	if err != nil {
+		return report, fmt.Errorf("writing groups: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/enrichman/portfolio-performance/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTags(t *testing.T) {
+	csv := `isin,name,loader,options
+"IT0005547408.MOT","Btp Valore Gn27 Eur","borsaitaliana","tags=btp"
+"IT0005532723.MOT","Btp Italia Mz28 Eur","borsaitaliana","tags=btp,govies"
+"FP-Cometa-Reddito.reddito","Fondo Pensione Cometa - Comparto Reddito","cometa","tags=cometa,pension"
+`
+	catalog, err := security.LoadSecuritiesFromCSV([]byte(csv))
+	require.Nil(t, err)
+
+	// the tags are normalised like the ones of the catalog
+	selected, tags, err := withTags(catalog, " BTP, Cometa ,btp")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"btp", "cometa"}, tags)
+	assert.Len(t, selected, 3)
+
+	_, _, err = withTags(catalog, "btp,cometta")
+	assert.ErrorContains(t, err, "cometta")
+
+	_, _, err = withTags(catalog, " , ")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/enrichman/portfolio-performance/pkg/security"
+)
+
+func validateCmd(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	online := flags.Bool("online", false, "fetch the quotes of every security, to check the loaders can reach them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: portfolio-performance validate [flags] [CATALOG...]")
+		fmt.Fprintln(flags.Output(), "Validates the catalogs, securities.csv if none is given. Every catalog is validated on its own.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"securities.csv"}
+	}
+
+	// the diagnostics are printed by the command, the logs of the loaders are only noise
+	if log.GetLevel() < log.ErrorLevel {
+		log.SetLevel(log.ErrorLevel)
+	}
+
+	errorsFound, warningsFound := 0, 0
+
+	for _, path := range paths {
+		catalogBytes, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading catalog: %w", err)
+		}
+
+		diagnostics, err := security.ValidateCatalog(catalogBytes, *online)
+		if err != nil {
+			return fmt.Errorf("validating catalog '%s': %w", path, err)
+		}
+
+		for _, d := range diagnostics {
+			fmt.Printf("%s:%s\n", path, d)
+			if d.Severity == security.SeverityError {
+				errorsFound++
+			} else {
+				warningsFound++
+			}
+		}
+	}
+
+	if errorsFound > 0 {
+		return fmt.Errorf("%d errors and %d warnings found", errorsFound, warningsFound)
+	}
+	fmt.Printf("%d warnings found\n", warningsFound)
+	return nil
+}